@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -33,6 +34,9 @@ type App struct {
 	cancel   context.CancelFunc
 	mu       sync.Mutex
 	instance *registry.ServiceInstance
+
+	registered atomic.Bool
+	started    atomic.Int64
 }
 
 // New create an application lifecycle manager.
@@ -80,6 +84,12 @@ func (a *App) Endpoint() []string {
 	return nil
 }
 
+// Registered reports whether the application is registered to the registry.
+func (a *App) Registered() bool { return a.registered.Load() }
+
+// ServersStarted returns the number of the running servers.
+func (a *App) ServersStarted() int { return int(a.started.Load()) }
+
 // Run executes all OnStart hooks registered with the application's Lifecycle.
 func (a *App) Run() error {
 	instance, err := a.buildInstance()
@@ -109,6 +119,8 @@ func (a *App) Run() error {
 		wg.Add(1)
 		eg.Go(func() error {
 			wg.Done() // here is to ensure server start has begun running before register, so defer is not needed
+			a.started.Add(1)
+			defer a.started.Add(-1)
 			return srv.Start(NewContext(a.opts.ctx, a))
 		})
 	}
@@ -119,6 +131,7 @@ func (a *App) Run() error {
 		if err = a.opts.registrar.Register(rctx, instance); err != nil {
 			return err
 		}
+		a.registered.Store(true)
 	}
 	for _, fn := range a.opts.afterStart {
 		if err = fn(sctx); err != nil {
@@ -162,6 +175,7 @@ func (a *App) Stop() (err error) {
 		if err = a.opts.registrar.Deregister(ctx, instance); err != nil {
 			return err
 		}
+		a.registered.Store(false)
 	}
 	if a.cancel != nil {
 		a.cancel()
@@ -12,6 +12,7 @@ import (
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
 	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/go-kratos/kratos/v2/transport/grpc"
 	"github.com/go-kratos/kratos/v2/transport/http"
@@ -123,6 +124,36 @@ func recoverWithSentry(ctx context.Context, opts options, hub *sentry.Hub, req i
 	}
 }
 
+// Reporter returns a recovery reporter sends the panics to Sentry, the hub of the
+// Server middleware is used if the recovery middleware is placed after it.
+// e.g. recovery.Recovery(recovery.WithReporter(recovery.LogReporter(nil), sentry.Reporter()))
+func Reporter(opts ...Option) recovery.Reporter {
+	conf := options{}
+	for _, o := range opts {
+		o(&conf)
+	}
+	if conf.timeout == 0 {
+		conf.timeout = 2 * time.Second
+	}
+	return recovery.ReporterFunc(func(ctx context.Context, p *recovery.Panic) {
+		if isBrokenPipeError(p.Value) {
+			return
+		}
+		hub := GetHubFromContext(ctx)
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetTag("panic.type", p.Type())
+			scope.SetTag("goroutine", fmt.Sprint(p.Goroutine))
+			if p.Operation != "" {
+				scope.SetTag("operation", p.Operation)
+			}
+			eventID := hub.RecoverWithContext(context.WithValue(ctx, sentry.RequestContextKey, p.Request), p.Value)
+			if eventID != nil && conf.waitForDelivery {
+				hub.Flush(conf.timeout)
+			}
+		})
+	})
+}
+
 func isBrokenPipeError(err interface{}) bool {
 	if netErr, ok := err.(*net.OpError); ok {
 		if sysErr, ok := netErr.Err.(*os.SyscallError); ok {
@@ -1,8 +1,16 @@
 package sentry
 
 import (
+	"context"
+	"net"
+	"os"
+	"syscall"
 	"testing"
 	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
 )
 
 func TestWithTags(t *testing.T) {
@@ -47,3 +55,44 @@ func TestWithTimeout(t *testing.T) {
 		t.Errorf("TestWithTimeout() = %v, want %v", opts.timeout, val)
 	}
 }
+
+type transportMock struct {
+	events []*sentry.Event
+}
+
+func (t *transportMock) Flush(time.Duration) bool      { return true }
+func (t *transportMock) Configure(sentry.ClientOptions) {}
+func (t *transportMock) SendEvent(event *sentry.Event) { t.events = append(t.events, event) }
+
+func TestReporter(t *testing.T) {
+	tr := &transportMock{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: tr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), ctxKey{}, sentry.NewHub(client, sentry.NewScope()))
+	h := recovery.Recovery(recovery.WithReporter(Reporter(WithWaitForDelivery(true))))(
+		func(context.Context, interface{}) (interface{}, error) {
+			panic("boom")
+		})
+	if _, err = h(ctx, nil); err == nil {
+		t.Fatal("expect the panic recovered as an error")
+	}
+	if len(tr.events) != 1 {
+		t.Fatalf("expect 1 event, got %v", len(tr.events))
+	}
+	event := tr.events[0]
+	if event.Message != "boom" || event.Tags["panic.type"] != "string" || event.Tags["goroutine"] != "false" {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	// the broken pipes are not reported
+	h = recovery.Recovery(recovery.WithReporter(Reporter()))(
+		func(context.Context, interface{}) (interface{}, error) {
+			panic(&net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)})
+		})
+	_, _ = h(ctx, nil)
+	if len(tr.events) != 1 {
+		t.Errorf("expect the broken pipe skipped, got %v events", len(tr.events))
+	}
+}
@@ -0,0 +1,71 @@
+package validate
+
+import (
+	"errors"
+
+	"github.com/bufbuild/protovalidate-go"
+	"google.golang.org/protobuf/proto"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/validate"
+)
+
+var _ validate.ProtoValidator = (*Validator)(nil)
+
+// Validator validates proto messages by the protovalidate (CEL based) constraints
+// in their descriptors, the compiled rules are cached per message type.
+type Validator struct {
+	validator *protovalidate.Validator
+}
+
+// New new a protovalidate validator.
+func New(opts ...protovalidate.ValidatorOption) (*Validator, error) {
+	v, err := protovalidate.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{validator: v}, nil
+}
+
+// Validate validates the message.
+func (v *Validator) Validate(msg proto.Message) error {
+	err := v.validator.Validate(msg)
+	if err == nil {
+		return nil
+	}
+	var verr *protovalidate.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	violations := make([]*kerrors.FieldViolation, 0, len(verr.Violations))
+	for _, violation := range verr.Violations {
+		violations = append(violations, &kerrors.FieldViolation{
+			Field:   violation.GetFieldPath(),
+			Rule:    violation.GetConstraintId(),
+			Message: violation.GetMessage(),
+		})
+	}
+	return &violationError{err: verr, violations: violations}
+}
+
+// Server is a validator middleware validates requests by protovalidate.
+func Server(opts ...protovalidate.ValidatorOption) (middleware.Middleware, error) {
+	v, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return validate.Validator(validate.WithProtoValidator(v)), nil
+}
+
+type violationError struct {
+	err        error
+	violations []*kerrors.FieldViolation
+}
+
+func (e *violationError) Error() string { return e.err.Error() }
+
+func (e *violationError) Unwrap() error { return e.err }
+
+// FieldViolations returns the field violations.
+func (e *violationError) FieldViolations() []*kerrors.FieldViolation { return e.violations }
@@ -0,0 +1,117 @@
+package validate
+
+import (
+	"context"
+	"testing"
+
+	pv "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/bufbuild/protovalidate-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// newRequest builds a message with protovalidate constraints without code generation.
+func newRequest(t *testing.T) protoreflect.MessageDescriptor {
+	nameOpts := &descriptorpb.FieldOptions{}
+	proto.SetExtension(nameOpts, pv.E_Field, &pv.FieldConstraints{
+		Type: &pv.FieldConstraints_String_{String_: &pv.StringRules{MinLen: proto.Uint64(1)}},
+	})
+	ageOpts := &descriptorpb.FieldOptions{}
+	proto.SetExtension(ageOpts, pv.E_Field, &pv.FieldConstraints{
+		Type: &pv.FieldConstraints_Int32{Int32: &pv.Int32Rules{GreaterThan: &pv.Int32Rules_Gt{Gt: 0}}},
+	})
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("kratos/test/validate.proto"),
+		Package:    proto.String("kratos.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"buf/validate/validate.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("HelloRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:     proto.String("name"),
+					JsonName: proto.String("name"),
+					Number:   proto.Int32(1),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					Options:  nameOpts,
+				},
+				{
+					Name:     proto.String("age"),
+					JsonName: proto.String("age"),
+					Number:   proto.Int32(2),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+					Options:  ageOpts,
+				},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd.Messages().ByName("HelloRequest")
+}
+
+func TestValidator(t *testing.T) {
+	desc := newRequest(t)
+	v, err := New(protovalidate.WithDescriptors(desc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("name"), protoreflect.ValueOfString("kratos"))
+	msg.Set(desc.Fields().ByName("age"), protoreflect.ValueOfInt32(18))
+	if err = v.Validate(msg); err != nil {
+		t.Errorf("expect %v, got %v", nil, err)
+	}
+
+	err = v.Validate(dynamicpb.NewMessage(desc))
+	if err == nil {
+		t.Fatal("expect validation error")
+	}
+	violations := err.(*violationError).FieldViolations()
+	want := []*errors.FieldViolation{
+		{Field: "name", Rule: "string.min_len", Message: "value length must be at least 1 characters"},
+		{Field: "age", Rule: "int32.gt", Message: "value must be greater than 0"},
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("expect %v, got %v", want, violations)
+	}
+	for i := range want {
+		if !proto.Equal(violations[i], want[i]) {
+			t.Errorf("expect %v, got %v", want[i], violations[i])
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	desc := newRequest(t)
+	m, err := Server()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := m(func(context.Context, interface{}) (interface{}, error) { return "ok", nil })
+
+	_, err = h(context.Background(), dynamicpb.NewMessage(desc))
+	if !errors.IsBadRequest(err) {
+		t.Fatalf("expect bad request, got %v", err)
+	}
+	if violations := errors.FieldViolations(err); len(violations) != 2 {
+		t.Errorf("expect %v violations, got %v", 2, violations)
+	}
+
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("name"), protoreflect.ValueOfString("kratos"))
+	msg.Set(desc.Fields().ByName("age"), protoreflect.ValueOfInt32(18))
+	if reply, err := h(context.Background(), msg); err != nil || reply != "ok" {
+		t.Errorf("expect %v, got %v, %v", "ok", reply, err)
+	}
+}
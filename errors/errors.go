@@ -6,6 +6,7 @@ import (
 
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
 
 	httpstatus "github.com/go-kratos/kratos/v2/transport/http/status"
 )
@@ -56,11 +57,17 @@ func (e *Error) WithMetadata(md map[string]string) *Error {
 
 // GRPCStatus returns the Status represented by se.
 func (e *Error) GRPCStatus() *status.Status {
-	s, _ := status.New(httpstatus.ToGRPCCode(int(e.Code)), e.Message).
-		WithDetails(&errdetails.ErrorInfo{
-			Reason:   e.Reason,
-			Metadata: e.Metadata,
-		})
+	details := []protoiface.MessageV1{&errdetails.ErrorInfo{
+		Reason:   e.Reason,
+		Metadata: e.Metadata,
+	}}
+	if len(e.FieldViolations) > 0 {
+		details = append(details, badRequestDetail(e.FieldViolations))
+		for _, v := range e.FieldViolations {
+			details = append(details, v)
+		}
+	}
+	s, _ := status.New(httpstatus.ToGRPCCode(int(e.Code)), e.Message).WithDetails(details...)
 	return s
 }
 
@@ -115,10 +122,11 @@ func Clone(err *Error) *Error {
 	return &Error{
 		cause: err.cause,
 		Status: Status{
-			Code:     err.Code,
-			Reason:   err.Reason,
-			Message:  err.Message,
-			Metadata: metadata,
+			Code:            err.Code,
+			Reason:          err.Reason,
+			Message:         err.Message,
+			Metadata:        metadata,
+			FieldViolations: cloneViolations(err.FieldViolations),
 		},
 	}
 }
@@ -141,12 +149,21 @@ func FromError(err error) *Error {
 		UnknownReason,
 		gs.Message(),
 	)
+	var badRequest []*FieldViolation
 	for _, detail := range gs.Details() {
 		switch d := detail.(type) {
 		case *errdetails.ErrorInfo:
 			ret.Reason = d.Reason
-			return ret.WithMetadata(d.Metadata)
+			ret = ret.WithMetadata(d.Metadata)
+		case *errdetails.BadRequest:
+			badRequest = fromBadRequestDetail(d)
+		case *FieldViolation:
+			// the kratos details keep the rules of the violations
+			ret.FieldViolations = append(ret.FieldViolations, d)
 		}
 	}
+	if len(ret.FieldViolations) == 0 {
+		ret.FieldViolations = badRequest
+	}
 	return ret
 }
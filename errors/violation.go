@@ -0,0 +1,61 @@
+package errors
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+)
+
+// WithFieldViolations with the field violations of a bad request, e.g. the field path,
+// the violated rule and the message. They are rendered in the HTTP body, and carried
+// as the BadRequest details and the FieldViolation details, which keep the rules,
+// in the gRPC status.
+func (e *Error) WithFieldViolations(violations ...*FieldViolation) *Error {
+	err := Clone(e)
+	err.FieldViolations = cloneViolations(violations)
+	return err
+}
+
+// FieldViolations returns the field violations of an error.
+// It supports wrapped errors.
+func FieldViolations(err error) []*FieldViolation {
+	se := FromError(err)
+	if se == nil {
+		return nil
+	}
+	return se.FieldViolations
+}
+
+func cloneViolations(violations []*FieldViolation) []*FieldViolation {
+	if len(violations) == 0 {
+		return nil
+	}
+	cloned := make([]*FieldViolation, 0, len(violations))
+	for _, v := range violations {
+		cloned = append(cloned, proto.Clone(v).(*FieldViolation))
+	}
+	return cloned
+}
+
+func badRequestDetail(violations []*FieldViolation) *errdetails.BadRequest {
+	br := &errdetails.BadRequest{
+		FieldViolations: make([]*errdetails.BadRequest_FieldViolation, 0, len(violations)),
+	}
+	for _, v := range violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Message,
+		})
+	}
+	return br
+}
+
+func fromBadRequestDetail(br *errdetails.BadRequest) []*FieldViolation {
+	violations := make([]*FieldViolation, 0, len(br.FieldViolations))
+	for _, v := range br.FieldViolations {
+		violations = append(violations, &FieldViolation{
+			Field:   v.Field,
+			Message: v.Description,
+		})
+	}
+	return violations
+}
@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func equalViolations(a, b []*FieldViolation) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFieldViolations(t *testing.T) {
+	violations := []*FieldViolation{
+		{Field: "name", Rule: "string.min_len", Message: "value length must be at least 1 runes"},
+		{Field: "user.age", Message: "value must be greater than 0"},
+	}
+	err := BadRequest("VALIDATOR", "invalid request").WithFieldViolations(violations...)
+	if got := FieldViolations(err); !equalViolations(got, violations) {
+		t.Errorf("expect %v, got %v", violations, got)
+	}
+	if FieldViolations(BadRequest("", "")) != nil {
+		t.Error("expect no violations")
+	}
+	if FieldViolations(nil) != nil {
+		t.Error("expect no violations")
+	}
+	if vs := FieldViolations(err.WithFieldViolations()); vs != nil {
+		t.Errorf("expect violations cleared, got %v", vs)
+	}
+	if vs := FieldViolations(Clone(err)); !equalViolations(vs, violations) {
+		t.Errorf("expect %v, got %v", violations, vs)
+	}
+	if len(err.Metadata) != 0 {
+		t.Errorf("expect no metadata, got %v", err.Metadata)
+	}
+
+	// gRPC round trip
+	gs := err.GRPCStatus()
+	var br *errdetails.BadRequest
+	for _, detail := range gs.Details() {
+		if d, ok := detail.(*errdetails.BadRequest); ok {
+			br = d
+		}
+	}
+	if br == nil || len(br.FieldViolations) != 2 || br.FieldViolations[1].Field != "user.age" {
+		t.Fatalf("expect bad request details, got %v", gs.Details())
+	}
+	if got := FieldViolations(gs.Err()); !equalViolations(got, violations) || got[0].Rule != "string.min_len" {
+		t.Errorf("expect %v, got %v", violations, got)
+	}
+}
+
+func TestFieldViolationsFromBadRequest(t *testing.T) {
+	gs, err := status.New(codes.InvalidArgument, "invalid request").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "name", Description: "required"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	se := FromError(gs.Err())
+	if se.Code != http.StatusBadRequest {
+		t.Errorf("expect %v, got %v", http.StatusBadRequest, se.Code)
+	}
+	want := []*FieldViolation{{Field: "name", Message: "required"}}
+	if got := FieldViolations(se); !equalViolations(got, want) {
+		t.Errorf("expect %v, got %v", want, got)
+	}
+}
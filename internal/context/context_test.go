@@ -278,3 +278,19 @@ func Test_Err2(t *testing.T) {
 		t.Errorf("expect %v, got %v", context.Canceled, ctx.Err())
 	}
 }
+
+func TestDetach(t *testing.T) {
+	type key struct{}
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	cancel()
+	ctx := Detach(parent)
+	if ctx.Err() != nil || ctx.Done() != nil {
+		t.Errorf("expect the detached context not canceled, got %v", ctx.Err())
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expect no deadline")
+	}
+	if ctx.Value(key{}) != "value" {
+		t.Errorf("expect %v, got %v", "value", ctx.Value(key{}))
+	}
+}
@@ -0,0 +1,21 @@
+package context
+
+import (
+	"context"
+	"time"
+)
+
+type detachedCtx struct {
+	parent context.Context
+}
+
+// Detach returns a context keeps the values of parent but is never canceled,
+// e.g. for the background work outliving the request.
+func Detach(parent context.Context) context.Context {
+	return detachedCtx{parent: parent}
+}
+
+func (detachedCtx) Deadline() (deadline time.Time, ok bool) { return }
+func (detachedCtx) Done() <-chan struct{}                   { return nil }
+func (detachedCtx) Err() error                              { return nil }
+func (c detachedCtx) Value(key interface{}) interface{}     { return c.parent.Value(key) }
@@ -0,0 +1,179 @@
+package redact
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Value is the value of the redacted string fields.
+const Value = "******"
+
+// Redactor redacts the sensitive fields of proto messages, the fields are
+// selected by paths, the debug_redact option or a bool extension option.
+type Redactor struct {
+	// paths is the dot-separated field paths to redact.
+	paths map[string]struct{}
+	// extension is the bool field option marks a field sensitive.
+	extension protoreflect.ExtensionType
+	// cache is whether a message type may contain sensitive fields.
+	cache sync.Map
+}
+
+// New new a redactor.
+func New() *Redactor {
+	return &Redactor{paths: make(map[string]struct{})}
+}
+
+// AddPaths adds the dot-separated field paths to redact, e.g. "user.password".
+func (r *Redactor) AddPaths(paths ...string) {
+	for _, path := range paths {
+		r.paths[path] = struct{}{}
+	}
+}
+
+// SetExtension sets the bool field option marks a field sensitive.
+func (r *Redactor) SetExtension(xt protoreflect.ExtensionType) {
+	r.extension = xt
+}
+
+// Redact returns a redacted copy of the message, or the message itself
+// when it has no sensitive fields.
+func (r *Redactor) Redact(m proto.Message) proto.Message {
+	md := m.ProtoReflect().Descriptor()
+	sensitive, ok := r.cache.Load(md.FullName())
+	if !ok {
+		sensitive, _ = r.cache.LoadOrStore(md.FullName(), r.sensitive(md))
+	}
+	if !sensitive.(bool) {
+		return m
+	}
+	m = proto.Clone(m)
+	r.redactMessage(m.ProtoReflect(), "")
+	return m
+}
+
+// sensitive reports whether the message may contain sensitive fields.
+func (r *Redactor) sensitive(md protoreflect.MessageDescriptor) bool {
+	for path := range r.paths {
+		if hasPath(md, path) {
+			return true
+		}
+	}
+	return r.sensitiveOption(md, make(map[protoreflect.FullName]struct{}))
+}
+
+// sensitiveOption reports whether the message may contain the fields marked sensitive
+// by the options, the recursive messages are walked once.
+func (r *Redactor) sensitiveOption(md protoreflect.MessageDescriptor, visited map[protoreflect.FullName]struct{}) bool {
+	if _, ok := visited[md.FullName()]; ok {
+		return false
+	}
+	visited[md.FullName()] = struct{}{}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if r.isSensitive(fd, "") {
+			return true
+		}
+		if sub := fieldMessage(fd); sub != nil && r.sensitiveOption(sub, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPath reports whether the dot-separated field path exists in the message.
+func hasPath(md protoreflect.MessageDescriptor, path string) bool {
+	for {
+		name, rest, more := strings.Cut(path, ".")
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return false
+		}
+		if !more {
+			return true
+		}
+		if md = fieldMessage(fd); md == nil {
+			return false
+		}
+		path = rest
+	}
+}
+
+func (r *Redactor) redactMessage(m protoreflect.Message, prefix string) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		path := join(prefix, fd)
+		if r.isSensitive(fd, path) {
+			if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+				m.Set(fd, protoreflect.ValueOfString(Value))
+			} else {
+				m.Clear(fd)
+			}
+			return true
+		}
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				r.redactMessage(list.Get(i).Message(), path)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				r.redactMessage(mv.Message(), path)
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			r.redactMessage(v.Message(), path)
+		}
+		return true
+	})
+}
+
+func (r *Redactor) isSensitive(fd protoreflect.FieldDescriptor, path string) bool {
+	if _, ok := r.paths[path]; ok {
+		return true
+	}
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || opts == nil {
+		return false
+	}
+	if opts.GetDebugRedact() {
+		return true
+	}
+	if r.extension != nil && proto.HasExtension(opts, r.extension) {
+		b, ok := proto.GetExtension(opts, r.extension).(bool)
+		return ok && b
+	}
+	return false
+}
+
+func fieldMessage(fd protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+	if fd.IsMap() {
+		return fd.MapValue().Message()
+	}
+	return fd.Message()
+}
+
+func join(prefix string, fd protoreflect.FieldDescriptor) string {
+	if prefix == "" {
+		return string(fd.Name())
+	}
+	return prefix + "." + string(fd.Name())
+}
+
+// Truncate returns s truncated to at most n bytes on a rune boundary with the suffix,
+// or s itself if it isn't longer than n bytes.
+func Truncate(s string, n int, suffix string) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + suffix
+}
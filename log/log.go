@@ -30,6 +30,14 @@ func (c *logger) Log(level Level, keyvals ...interface{}) error {
 	return c.logger.Log(level, kvs...)
 }
 
+// ContextLogger is a logger depends on the context of WithContext,
+// e.g. to correlate the logs with the trace in ctx.
+type ContextLogger interface {
+	Logger
+	// WithContext returns a shallow copy of the logger with its context changed to ctx.
+	WithContext(ctx context.Context) Logger
+}
+
 // With with logger fields.
 func With(l Logger, kv ...interface{}) Logger {
 	c, ok := l.(*logger)
@@ -53,9 +61,14 @@ func WithContext(ctx context.Context, l Logger) Logger {
 	switch v := l.(type) {
 	default:
 		return &logger{logger: l, ctx: ctx}
+	case ContextLogger:
+		return v.WithContext(ctx)
 	case *logger:
 		lv := *v
 		lv.ctx = ctx
+		if cl, ok := lv.logger.(ContextLogger); ok {
+			lv.logger = cl.WithContext(ctx)
+		}
 		return &lv
 	case *Filter:
 		fv := *v
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"context"
 	"testing"
 )
 
@@ -10,3 +11,30 @@ func TestInfo(_ *testing.T) {
 	logger = With(logger, "caller", DefaultCaller)
 	_ = logger.Log(LevelInfo, "key1", "value1")
 }
+
+type ctxLogger struct {
+	ctx context.Context
+	out *[]interface{}
+}
+
+func (l *ctxLogger) Log(_ Level, _ ...interface{}) error {
+	*l.out = append(*l.out, l.ctx.Value(ctxKey{}))
+	return nil
+}
+
+func (l *ctxLogger) WithContext(ctx context.Context) Logger {
+	return &ctxLogger{ctx: ctx, out: l.out}
+}
+
+type ctxKey struct{}
+
+func TestContextLogger(t *testing.T) {
+	var out []interface{}
+	l := With(&ctxLogger{ctx: context.Background(), out: &out}, "k", "v")
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	_ = WithContext(ctx, l).Log(LevelInfo, "msg", "a")
+	_ = WithContext(ctx, NewFilter(l)).Log(LevelInfo, "msg", "b")
+	if len(out) != 2 || out[0] != "value" || out[1] != "value" {
+		t.Errorf("expect the context propagated, got %v", out)
+	}
+}
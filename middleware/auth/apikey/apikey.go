@@ -0,0 +1,141 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// DefaultHeader is the default request header of the api key,
+// it's also the gRPC metadata key.
+const DefaultHeader = "x-api-key"
+
+// reason holds the error reason.
+const reason string = "UNAUTHORIZED"
+
+var (
+	ErrMissingKey   = errors.Unauthorized(reason, "API key is missing")
+	ErrInvalidKey   = errors.Unauthorized(reason, "API key is invalid")
+	ErrWrongContext = errors.Unauthorized(reason, "Wrong context for middleware")
+)
+
+type keyKey struct{}
+
+// Key is an API key record.
+type Key struct {
+	// ID is the key identity, it's safe to log unlike the key itself.
+	ID string
+	// Owner is the partner or the service owns the key.
+	Owner string
+	// Metadata is the additional attributes of the key, e.g. scopes.
+	Metadata map[string]string
+}
+
+// Store looks up the API keys, a nil key with nil error means the key is unknown.
+type Store interface {
+	Lookup(ctx context.Context, key string) (*Key, error)
+}
+
+// StoreFunc is a function adapter of Store.
+type StoreFunc func(ctx context.Context, key string) (*Key, error)
+
+// Lookup implements Store.
+func (f StoreFunc) Lookup(ctx context.Context, key string) (*Key, error) {
+	return f(ctx, key)
+}
+
+// StaticStore returns a Store of the fixed keys, keyed by the API key.
+func StaticStore(keys map[string]*Key) Store {
+	// index by the digest so that the lookup time doesn't depend on the key prefix
+	digests := make(map[[sha256.Size]byte]*Key, len(keys))
+	for k, v := range keys {
+		digests[sha256.Sum256([]byte(k))] = v
+	}
+	return StoreFunc(func(_ context.Context, key string) (*Key, error) {
+		return digests[sha256.Sum256([]byte(key))], nil
+	})
+}
+
+// Option is api key option.
+type Option func(*options)
+
+type options struct {
+	header string
+}
+
+// WithHeader with the request header or gRPC metadata key of the api key.
+func WithHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// Server is a server api key authentication middleware.
+func Server(store Store, opts ...Option) middleware.Middleware {
+	o := &options{header: DefaultHeader}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			apiKey := tr.RequestHeader().Get(o.header)
+			if apiKey == "" {
+				return nil, ErrMissingKey
+			}
+			key, err := store.Lookup(ctx, apiKey)
+			if err != nil {
+				return nil, ErrInvalidKey.WithCause(err)
+			}
+			if key == nil {
+				return nil, ErrInvalidKey
+			}
+			return handler(NewContext(ctx, key), req)
+		}
+	}
+}
+
+// Client is a client api key middleware.
+func Client(apiKey string, opts ...Option) middleware.Middleware {
+	o := &options{header: DefaultHeader}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			tr.RequestHeader().Set(o.header, apiKey)
+			return handler(ctx, req)
+		}
+	}
+}
+
+// NewContext put the api key record into context.
+func NewContext(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// FromContext extract the api key record from context.
+func FromContext(ctx context.Context) (key *Key, ok bool) {
+	key, ok = ctx.Value(keyKey{}).(*Key)
+	return
+}
+
+// IDFromContext extract the id of the api key record from context,
+// e.g. as the key of the per caller rate limit or cache.
+func IDFromContext(ctx context.Context) (id string, ok bool) {
+	key, ok := FromContext(ctx)
+	if !ok || key == nil {
+		return "", false
+	}
+	return key.ID, true
+}
@@ -0,0 +1,103 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string  { return hc[key] }
+func (hc headerCarrier) Set(key, value string)  { hc[key] = value }
+func (hc headerCarrier) Add(key, value string)  { hc[key] = value }
+func (hc headerCarrier) Keys() []string         { return nil }
+func (hc headerCarrier) Values(string) []string { return nil }
+
+type Transport struct {
+	header headerCarrier
+}
+
+func (tr *Transport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *Transport) Endpoint() string                { return "" }
+func (tr *Transport) Operation() string               { return "/test.Service/Method" }
+func (tr *Transport) RequestHeader() transport.Header { return tr.header }
+func (tr *Transport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestServer(t *testing.T) {
+	store := StaticStore(map[string]*Key{
+		"secret-key": {ID: "k1", Owner: "partner"},
+	})
+	h := Server(store)(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		key, ok := FromContext(ctx)
+		if !ok {
+			return nil, errors.New("missing key")
+		}
+		return key.Owner, nil
+	})
+
+	tests := []struct {
+		name   string
+		header headerCarrier
+		err    error
+	}{
+		{"valid", headerCarrier{DefaultHeader: "secret-key"}, nil},
+		{"invalid", headerCarrier{DefaultHeader: "other-key"}, ErrInvalidKey},
+		{"missing", headerCarrier{}, ErrMissingKey},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := transport.NewServerContext(context.Background(), &Transport{header: test.header})
+			reply, err := h(ctx, nil)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expect %v, got %v", test.err, err)
+			}
+			if err == nil && reply != "partner" {
+				t.Errorf("expect %v, got %v", "partner", reply)
+			}
+		})
+	}
+	if _, err := h(context.Background(), nil); !errors.Is(err, ErrWrongContext) {
+		t.Errorf("expect %v, got %v", ErrWrongContext, err)
+	}
+
+	storeErr := errors.New("store unavailable")
+	h = Server(StoreFunc(func(context.Context, string) (*Key, error) {
+		return nil, storeErr
+	}))(nil)
+	ctx := transport.NewServerContext(context.Background(), &Transport{header: headerCarrier{DefaultHeader: "secret-key"}})
+	if _, err := h(ctx, nil); !errors.Is(err, ErrInvalidKey) || !errors.Is(err, storeErr) {
+		t.Errorf("expect %v, got %v", ErrInvalidKey, err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	header := headerCarrier{}
+	ctx := transport.NewClientContext(context.Background(), &Transport{header: header})
+	h := Client("secret-key", WithHeader("x-partner-key"))(func(context.Context, interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+	if _, err := h(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v := header.Get("x-partner-key"); v != "secret-key" {
+		t.Errorf("expect %v, got %v", "secret-key", v)
+	}
+	if _, err := h(context.Background(), nil); !errors.Is(err, ErrWrongContext) {
+		t.Errorf("expect %v, got %v", ErrWrongContext, err)
+	}
+}
+
+func TestIDFromContext(t *testing.T) {
+	if id, ok := IDFromContext(NewContext(context.Background(), &Key{ID: "k1"})); !ok || id != "k1" {
+		t.Errorf("expect %v, got %v", "k1", id)
+	}
+	if _, ok := IDFromContext(NewContext(context.Background(), nil)); ok {
+		t.Error("expect no id of the nil key")
+	}
+	if _, ok := IDFromContext(context.Background()); ok {
+		t.Error("expect no id")
+	}
+}
@@ -0,0 +1,247 @@
+package hmac
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// The request headers of the signature, they're also the gRPC metadata keys.
+const (
+	KeyIDHeader     = "x-auth-key-id"
+	TimestampHeader = "x-auth-timestamp"
+	NonceHeader     = "x-auth-nonce"
+	SignatureHeader = "x-auth-signature"
+)
+
+// reason holds the error reason.
+const reason string = "UNAUTHORIZED"
+
+var (
+	ErrMissingSignature = errors.Unauthorized(reason, "Request signature is missing")
+	ErrInvalidSignature = errors.Unauthorized(reason, "Request signature is invalid")
+	ErrUnknownKey       = errors.Unauthorized(reason, "Signing key is unknown")
+	ErrTimestampExpired = errors.Unauthorized(reason, "Request timestamp is out of the window")
+	ErrReplayedRequest  = errors.Unauthorized(reason, "Request nonce has been used")
+	ErrWrongContext     = errors.Unauthorized(reason, "Wrong context for middleware")
+)
+
+type keyIDKey struct{}
+
+// SecretStore looks up the signing secrets, a nil secret with nil error
+// means the key id is unknown.
+type SecretStore interface {
+	Secret(ctx context.Context, keyID string) ([]byte, error)
+}
+
+// SecretStoreFunc is a function adapter of SecretStore.
+type SecretStoreFunc func(ctx context.Context, keyID string) ([]byte, error)
+
+// Secret implements SecretStore.
+func (f SecretStoreFunc) Secret(ctx context.Context, keyID string) ([]byte, error) {
+	return f(ctx, keyID)
+}
+
+// StaticSecrets returns a SecretStore of the fixed secrets, keyed by the key id.
+func StaticSecrets(secrets map[string]string) SecretStore {
+	return SecretStoreFunc(func(_ context.Context, keyID string) ([]byte, error) {
+		if secret, ok := secrets[keyID]; ok {
+			return []byte(secret), nil
+		}
+		return nil, nil
+	})
+}
+
+// Option is hmac option.
+type Option func(*options)
+
+type options struct {
+	window time.Duration
+	nonces NonceCache
+}
+
+// WithWindow with the accepted clock skew of the request timestamp, default is 5 minutes.
+func WithWindow(window time.Duration) Option {
+	return func(o *options) {
+		o.window = window
+	}
+}
+
+// WithNonceCache with the cache of the used nonces, default is an in-memory cache,
+// use a shared cache such as redis for multiple instances.
+func WithNonceCache(c NonceCache) Option {
+	return func(o *options) {
+		o.nonces = c
+	}
+}
+
+// Server is a server hmac authentication middleware.
+//
+// The signature is the hex HMAC-SHA256 of the string:
+//
+//	METHOD \n PATH \n TIMESTAMP \n NONCE \n HEX(SHA256(BODY))
+//
+// For HTTP, METHOD and PATH are the request method and URI, BODY is the raw request body.
+// For gRPC, METHOD is POST, PATH is the operation, BODY is the deterministic
+// protobuf encoding of the request message.
+func Server(store SecretStore, opts ...Option) middleware.Middleware {
+	o := &options{
+		window: 5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.nonces == nil {
+		o.nonces = NewMemoryNonceCache()
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			header := tr.RequestHeader()
+			keyID, timestamp, nonce, signature := header.Get(KeyIDHeader), header.Get(TimestampHeader), header.Get(NonceHeader), header.Get(SignatureHeader)
+			if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+				return nil, ErrMissingSignature
+			}
+			sec, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				return nil, ErrInvalidSignature
+			}
+			if skew := time.Since(time.Unix(sec, 0)); skew > o.window || skew < -o.window {
+				return nil, ErrTimestampExpired
+			}
+			secret, err := store.Secret(ctx, keyID)
+			if err != nil {
+				return nil, ErrUnknownKey.WithCause(err)
+			}
+			if secret == nil {
+				return nil, ErrUnknownKey
+			}
+			method, path, body, err := requestContent(tr, req)
+			if err != nil {
+				return nil, ErrInvalidSignature.WithCause(err)
+			}
+			expected := Sign(secret, method, path, timestamp, nonce, body)
+			if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+				return nil, ErrInvalidSignature
+			}
+			// the nonce is kept for two windows which covers the whole accepted timestamp range
+			if !o.nonces.Add(keyID+":"+nonce, 2*o.window) {
+				return nil, ErrReplayedRequest
+			}
+			return handler(NewContext(ctx, keyID), req)
+		}
+	}
+}
+
+// Client is a client hmac signing middleware.
+func Client(keyID, secret string) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			method, path, body, err := requestContent(tr, req)
+			if err != nil {
+				return nil, err
+			}
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			nonce, err := newNonce()
+			if err != nil {
+				return nil, err
+			}
+			header := tr.RequestHeader()
+			header.Set(KeyIDHeader, keyID)
+			header.Set(TimestampHeader, timestamp)
+			header.Set(NonceHeader, nonce)
+			header.Set(SignatureHeader, Sign([]byte(secret), method, path, timestamp, nonce, body))
+			return handler(ctx, req)
+		}
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 signature of the request.
+func Sign(secret []byte, method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join([]string{method, path, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// requestContent returns the signed method, path and body of the request.
+func requestContent(tr transport.Transporter, req interface{}) (string, string, []byte, error) {
+	if ht, ok := tr.(khttp.Transporter); ok && ht.Request() != nil {
+		r := ht.Request()
+		body, err := readBody(r)
+		return r.Method, r.URL.RequestURI(), body, err
+	}
+	var (
+		body []byte
+		err  error
+	)
+	switch m := req.(type) {
+	case nil:
+	case proto.Message:
+		body, err = proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	default:
+		body, err = json.Marshal(m)
+	}
+	return http.MethodPost, tr.Operation(), body, err
+}
+
+// readBody reads the request body and resets it so that it can be read again.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.GetBody != nil {
+		// the client request
+		rc, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewContext put the key id of the verified signature into context.
+func NewContext(ctx context.Context, keyID string) context.Context {
+	return context.WithValue(ctx, keyIDKey{}, keyID)
+}
+
+// FromContext extract the key id of the verified signature from context.
+func FromContext(ctx context.Context) (keyID string, ok bool) {
+	keyID, ok = ctx.Value(keyIDKey{}).(string)
+	return
+}
@@ -0,0 +1,151 @@
+package hmac
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string  { return hc[key] }
+func (hc headerCarrier) Set(key, value string)  { hc[key] = value }
+func (hc headerCarrier) Add(key, value string)  { hc[key] = value }
+func (hc headerCarrier) Keys() []string         { return nil }
+func (hc headerCarrier) Values(string) []string { return nil }
+
+type Transport struct {
+	operation string
+	header    headerCarrier
+}
+
+func (tr *Transport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *Transport) Endpoint() string                { return "" }
+func (tr *Transport) Operation() string               { return tr.operation }
+func (tr *Transport) RequestHeader() transport.Header { return tr.header }
+func (tr *Transport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func echo(ctx context.Context, req interface{}) (interface{}, error) {
+	keyID, _ := FromContext(ctx)
+	return keyID, nil
+}
+
+func TestGRPC(t *testing.T) {
+	store := StaticSecrets(map[string]string{"partner": "secret"})
+	server := Server(store)(echo)
+
+	sign := func(secret string, req interface{}) headerCarrier {
+		header := headerCarrier{}
+		ctx := transport.NewClientContext(context.Background(), &Transport{operation: "/test.Service/Method", header: header})
+		if _, err := Client("partner", secret)(echo)(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		return header
+	}
+	serverContext := func(header headerCarrier) context.Context {
+		return transport.NewServerContext(context.Background(), &Transport{operation: "/test.Service/Method", header: header})
+	}
+
+	req := wrapperspb.String("hello")
+	header := sign("secret", req)
+	if reply, err := server(serverContext(header), req); err != nil || reply != "partner" {
+		t.Fatalf("expect %v, got %v %v", "partner", reply, err)
+	}
+	if _, err := server(serverContext(header), req); !errors.Is(err, ErrReplayedRequest) {
+		t.Errorf("expect %v, got %v", ErrReplayedRequest, err)
+	}
+	header = sign("secret", req)
+	if _, err := server(serverContext(header), wrapperspb.String("tampered")); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expect %v, got %v", ErrInvalidSignature, err)
+	}
+	if _, err := server(serverContext(sign("wrong", req)), req); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expect %v, got %v", ErrInvalidSignature, err)
+	}
+
+	header = sign("secret", req)
+	header[KeyIDHeader] = "unknown"
+	if _, err := server(serverContext(header), req); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expect %v, got %v", ErrUnknownKey, err)
+	}
+	header = sign("secret", req)
+	header[TimestampHeader] = strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	if _, err := server(serverContext(header), req); !errors.Is(err, ErrTimestampExpired) {
+		t.Errorf("expect %v, got %v", ErrTimestampExpired, err)
+	}
+	if _, err := server(serverContext(headerCarrier{}), req); !errors.Is(err, ErrMissingSignature) {
+		t.Errorf("expect %v, got %v", ErrMissingSignature, err)
+	}
+}
+
+type echoRequest struct {
+	Name string `json:"name"`
+}
+
+func TestHTTP(t *testing.T) {
+	srv := khttp.NewServer(khttp.Middleware(Server(StaticSecrets(map[string]string{"partner": "secret"}))))
+	srv.Route("/").POST("/echo", func(ctx khttp.Context) error {
+		var in echoRequest
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		reply, err := ctx.Middleware(echo)(ctx, &in)
+		if err != nil {
+			return err
+		}
+		return ctx.Result(http.StatusOK, map[string]string{"key_id": reply.(string), "name": in.Name})
+	})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client, err := khttp.NewClient(context.Background(),
+		khttp.WithEndpoint(ts.Listener.Addr().String()),
+		khttp.WithMiddleware(Client("partner", "secret")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var reply map[string]string
+	if err = client.Invoke(context.Background(), http.MethodPost, "/echo?lang=en", &echoRequest{Name: "kratos"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply["key_id"] != "partner" || reply["name"] != "kratos" {
+		t.Errorf("unexpected reply %v", reply)
+	}
+
+	client, err = khttp.NewClient(context.Background(),
+		khttp.WithEndpoint(ts.Listener.Addr().String()),
+		khttp.WithMiddleware(Client("partner", "wrong")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	err = client.Invoke(context.Background(), http.MethodPost, "/echo", &echoRequest{Name: "kratos"}, &reply)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expect %v, got %v", ErrInvalidSignature, err)
+	}
+}
+
+func TestMemoryNonceCache(t *testing.T) {
+	c := NewMemoryNonceCache()
+	if !c.Add("a", 50*time.Millisecond) || c.Add("a", 50*time.Millisecond) {
+		t.Fatal("expect the nonce added once")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !c.Add("a", 50*time.Millisecond) {
+		t.Error("expect the expired nonce added again")
+	}
+	if n := len(c.(*memoryNonceCache).nonces); n != 1 {
+		t.Errorf("expect %v nonce, got %v", 1, n)
+	}
+}
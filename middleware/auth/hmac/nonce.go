@@ -0,0 +1,43 @@
+package hmac
+
+import (
+	"sync"
+	"time"
+)
+
+// NonceCache remembers the used nonces for replay protection.
+type NonceCache interface {
+	// Add adds the nonce for the ttl, it returns false if the nonce is already present.
+	Add(nonce string, ttl time.Duration) bool
+}
+
+// NewMemoryNonceCache new an in-memory nonce cache.
+func NewMemoryNonceCache() NonceCache {
+	return &memoryNonceCache{nonces: make(map[string]time.Time)}
+}
+
+type memoryNonceCache struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	// lastSweep is the last time the expired nonces were removed.
+	lastSweep time.Time
+}
+
+func (c *memoryNonceCache) Add(nonce string, ttl time.Duration) bool {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastSweep) > ttl {
+		for k, expiry := range c.nonces {
+			if now.After(expiry) {
+				delete(c.nonces, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	if expiry, ok := c.nonces[nonce]; ok && now.Before(expiry) {
+		return false
+	}
+	c.nonces[nonce] = now.Add(ttl)
+	return true
+}
@@ -0,0 +1,93 @@
+package jwt
+
+import (
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// Extractor extracts the token from the server transport.
+type Extractor func(tr transport.Transporter) (string, bool)
+
+// Injector injects the token into the client transport.
+type Injector func(tr transport.Transporter, token string)
+
+// FromHeader returns an Extractor reads the token from the request header,
+// the value must start with the scheme if it's not empty, e.g. "Bearer".
+func FromHeader(name, scheme string) Extractor {
+	return func(tr transport.Transporter) (string, bool) {
+		value := tr.RequestHeader().Get(name)
+		if scheme == "" {
+			return value, value != ""
+		}
+		auths := strings.SplitN(value, " ", 2)
+		if len(auths) != 2 || !strings.EqualFold(auths[0], scheme) || auths[1] == "" {
+			return "", false
+		}
+		return auths[1], true
+	}
+}
+
+// FromMetadata returns an Extractor reads the token from the gRPC metadata key
+// or the HTTP header as is.
+func FromMetadata(key string) Extractor {
+	return FromHeader(key, "")
+}
+
+// FromCookie returns an Extractor reads the token from the HTTP cookie.
+func FromCookie(name string) Extractor {
+	return func(tr transport.Transporter) (string, bool) {
+		ht, ok := tr.(http.Transporter)
+		if !ok {
+			return "", false
+		}
+		cookie, err := ht.Request().Cookie(name)
+		if err != nil || cookie.Value == "" {
+			return "", false
+		}
+		return cookie.Value, true
+	}
+}
+
+// FromQuery returns an Extractor reads the token from the HTTP query parameter,
+// e.g. for WebSocket and SSE clients which can't set headers.
+func FromQuery(param string) Extractor {
+	return func(tr transport.Transporter) (string, bool) {
+		ht, ok := tr.(http.Transporter)
+		if !ok {
+			return "", false
+		}
+		token := ht.Request().URL.Query().Get(param)
+		return token, token != ""
+	}
+}
+
+// Chain returns an Extractor tries the extractors in order and returns the first token found.
+func Chain(extractors ...Extractor) Extractor {
+	return func(tr transport.Transporter) (string, bool) {
+		for _, extract := range extractors {
+			if token, ok := extract(tr); ok {
+				return token, true
+			}
+		}
+		return "", false
+	}
+}
+
+// ToHeader returns an Injector sets the token into the request header,
+// prefixed with the scheme if it's not empty.
+func ToHeader(name, scheme string) Injector {
+	return func(tr transport.Transporter, token string) {
+		if scheme != "" {
+			token = scheme + " " + token
+		}
+		tr.RequestHeader().Set(name, token)
+	}
+}
+
+// ToMetadata returns an Injector sets the token into the gRPC metadata key
+// or the HTTP header as is.
+func ToMetadata(key string) Injector {
+	return ToHeader(key, "")
+}
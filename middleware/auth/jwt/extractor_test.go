@@ -0,0 +1,154 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type httpTransport struct {
+	Transport
+	request *http.Request
+}
+
+func (tr *httpTransport) Request() *http.Request { return tr.request }
+func (tr *httpTransport) PathTemplate() string   { return tr.request.URL.Path }
+
+func newHTTPTransport(req *http.Request) *httpTransport {
+	return &httpTransport{
+		Transport: Transport{kind: transport.KindHTTP, reqHeader: headerCarrier(req.Header)},
+		request:   req,
+	}
+}
+
+func TestExtractor(t *testing.T) {
+	extract := Chain(
+		FromHeader("X-Token", "Token"),
+		FromMetadata("x-md-token"),
+		FromCookie("session"),
+		FromQuery("access_token"),
+	)
+	tests := []struct {
+		name  string
+		req   func() *http.Request
+		token string
+	}{
+		{"header", func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Token", "token header")
+			return req
+		}, "header"},
+		{"metadata", func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Token", "Bearer wrong-scheme")
+			req.Header.Set("x-md-token", "metadata")
+			return req
+		}, "metadata"},
+		{"cookie", func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/?access_token=query", nil)
+			req.AddCookie(&http.Cookie{Name: "session", Value: "cookie"})
+			return req
+		}, "cookie"},
+		{"query", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/events?access_token=query", nil)
+		}, "query"},
+		{"missing", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/", nil)
+		}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, ok := extract(newHTTPTransport(test.req()))
+			if token != test.token || ok != (test.token != "") {
+				t.Errorf("expect %q, got %q", test.token, token)
+			}
+		})
+	}
+
+	// the HTTP-only extractors skip the other transports
+	tr := &Transport{kind: transport.KindGRPC, reqHeader: newTokenHeader("x-md-token", "grpc")}
+	if token, ok := extract(tr); !ok || token != "grpc" {
+		t.Errorf("expect %q, got %q", "grpc", token)
+	}
+	if _, ok := Chain(FromCookie("session"), FromQuery("access_token"))(tr); ok {
+		t.Error("expect no token")
+	}
+}
+
+func TestServerWithExtractor(t *testing.T) {
+	testKey := "testKey"
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "cookie"}).SignedString([]byte(testKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: token})
+	ctx := transport.NewServerContext(context.Background(), newHTTPTransport(req))
+
+	h := Server(func(*jwt.Token) (interface{}, error) { return []byte(testKey), nil },
+		WithExtractor(Chain(FromHeader(authorizationKey, bearerWord), FromCookie("session"))),
+	)(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		claims, _ := FromContext(ctx)
+		return claims.GetSubject()
+	})
+	reply, err := h(ctx, nil)
+	if err != nil || reply != "cookie" {
+		t.Errorf("expect %v, got %v %v", "cookie", reply, err)
+	}
+}
+
+func TestClientWithTokenSource(t *testing.T) {
+	var calls int32
+	source := TokenSourceFunc(func(context.Context) (string, error) {
+		n := atomic.AddInt32(&calls, 1)
+		claims := jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+		if n > 1 {
+			claims.Subject = "refreshed"
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("idp"))
+	})
+
+	header := &headerCarrier{}
+	ctx := transport.NewClientContext(context.Background(), &Transport{reqHeader: header})
+	next := func(context.Context, interface{}) (interface{}, error) { return "reply", nil }
+
+	h := Client(nil, WithTokenSource(ReuseTokenSource(source, time.Minute)), WithInjector(ToMetadata("x-token")))(next)
+	for i := 0; i < 3; i++ {
+		if _, err := h(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expect the token reused, got %v calls", calls)
+	}
+	if header.Get("x-token") == "" || header.Get(authorizationKey) != "" {
+		t.Errorf("unexpected header %v", header)
+	}
+
+	// the token about to expire is refreshed
+	h = Client(nil, WithTokenSource(ReuseTokenSource(source, 2*time.Hour)))(next)
+	for i := 0; i < 2; i++ {
+		if _, err := h(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("expect %v calls, got %v", 3, calls)
+	}
+
+	sourceErr := errors.New("idp unavailable")
+	h = Client(nil, WithTokenSource(TokenSourceFunc(func(context.Context) (string, error) {
+		return "", sourceErr
+	})))(next)
+	if _, err := h(ctx, nil); !errors.Is(err, ErrGetToken) || !errors.Is(err, sourceErr) {
+		t.Errorf("expect %v, got %v", ErrGetToken, err)
+	}
+}
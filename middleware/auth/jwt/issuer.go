@@ -0,0 +1,58 @@
+package jwt
+
+import (
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Issuer is a trusted token issuer.
+type Issuer struct {
+	// Issuer is the expected iss claim.
+	Issuer string
+	// Audience is the accepted aud claims, the token must carry any of them,
+	// empty means the audience is not checked.
+	Audience []string
+	// Keyfunc resolves the verification key of the issuer, e.g. JWKS.Keyfunc.
+	Keyfunc jwt.Keyfunc
+}
+
+// MultiIssuer returns a jwt.Keyfunc that selects the issuer by the iss claim of the token,
+// validates the aud claim and resolves the key with the Keyfunc of the issuer.
+func MultiIssuer(issuers ...Issuer) jwt.Keyfunc {
+	byIssuer := make(map[string]Issuer, len(issuers))
+	for _, iss := range issuers {
+		byIssuer[iss.Issuer] = iss
+	}
+	return func(token *jwt.Token) (interface{}, error) {
+		iss, err := token.Claims.GetIssuer()
+		if err != nil {
+			return nil, err
+		}
+		issuer, ok := byIssuer[iss]
+		if !ok {
+			return nil, fmt.Errorf("jwt: untrusted issuer %q", iss)
+		}
+		if len(issuer.Audience) > 0 {
+			aud, err := token.Claims.GetAudience()
+			if err != nil {
+				return nil, err
+			}
+			if !containsAny(aud, issuer.Audience) {
+				return nil, fmt.Errorf("jwt: invalid audience %v for issuer %q", aud, iss)
+			}
+		}
+		return issuer.Keyfunc(token)
+	}
+}
+
+func containsAny(values, targets []string) bool {
+	for _, v := range values {
+		for _, t := range targets {
+			if v == t {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// JWKSOption is JWKS option.
+type JWKSOption func(*JWKS)
+
+// WithRefreshInterval with the interval of the background refresh, default is 1 hour.
+func WithRefreshInterval(interval time.Duration) JWKSOption {
+	return func(k *JWKS) {
+		k.refreshInterval = interval
+	}
+}
+
+// WithRefreshRateLimit with the minimum interval of the refreshes triggered
+// by unknown key ids, default is 1 minute.
+func WithRefreshRateLimit(limit time.Duration) JWKSOption {
+	return func(k *JWKS) {
+		k.refreshLimit = limit
+	}
+}
+
+// WithHTTPClient with the http client to fetch the key set.
+func WithHTTPClient(client *http.Client) JWKSOption {
+	return func(k *JWKS) {
+		k.client = client
+	}
+}
+
+// JWKS is a JSON Web Key Set fetched from a remote URL, e.g. the jwks_uri of an OIDC provider.
+// The keys are cached and refreshed in the background, and an unknown key id
+// triggers an early refresh so that rotated keys are picked up.
+type JWKS struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	refreshLimit    time.Duration
+	cancel          context.CancelFunc
+
+	// refreshMu serializes the refreshes.
+	refreshMu   sync.Mutex
+	mu          sync.RWMutex
+	keys        map[string]*jwk
+	lastAttempt time.Time
+}
+
+// NewJWKS new a JWKS with the key set url, the keys are fetched before it returns.
+func NewJWKS(url string, opts ...JWKSOption) (*JWKS, error) {
+	k := &JWKS{
+		url:             url,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		refreshInterval: time.Hour,
+		refreshLimit:    time.Minute,
+	}
+	for _, o := range opts {
+		o(k)
+	}
+	if err := k.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	k.cancel = cancel
+	go k.refreshLoop(ctx)
+	return k, nil
+}
+
+func (k *JWKS) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(k.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Refresh(ctx); err != nil {
+				log.Errorf("jwt: failed to refresh jwks %s: %v", k.url, err)
+			}
+		}
+	}
+}
+
+// Refresh fetches the key set, the cached keys are kept on failure.
+func (k *JWKS) Refresh(ctx context.Context) error {
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+	return k.fetch(ctx)
+}
+
+// refreshStale refreshes the key set unless it was attempted within the rate limit.
+func (k *JWKS) refreshStale(ctx context.Context) error {
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+	if !k.refreshable() {
+		return nil
+	}
+	return k.fetch(ctx)
+}
+
+func (k *JWKS) fetch(ctx context.Context) error {
+	// the failed attempts count, so an unavailable provider isn't flooded by the unknown key ids
+	k.mu.Lock()
+	k.lastAttempt = time.Now()
+	k.mu.Unlock()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwt: unexpected jwks status %d", resp.StatusCode)
+	}
+	var set struct {
+		Keys []*jwk `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+	keys := make(map[string]*jwk, len(set.Keys))
+	for _, key := range set.Keys {
+		// skip the encryption keys and the unsupported key types
+		if key.Use == "enc" {
+			continue
+		}
+		if key.key, err = key.parse(); err != nil {
+			log.Warnf("jwt: skip jwk %q: %v", key.Kid, err)
+			continue
+		}
+		keys[key.Kid] = key
+	}
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+// Keyfunc is the jwt.Keyfunc selects the key by the kid header of the token,
+// all the keys are tried when the token has no kid.
+func (k *JWKS) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		k.mu.RLock()
+		defer k.mu.RUnlock()
+		set := jwt.VerificationKeySet{Keys: make([]jwt.VerificationKey, 0, len(k.keys))}
+		for _, key := range k.keys {
+			if key.matchAlg(token) {
+				set.Keys = append(set.Keys, key.key)
+			}
+		}
+		return set, nil
+	}
+	key, ok := k.lookup(kid)
+	if !ok && k.refreshable() {
+		if err := k.refreshStale(context.Background()); err != nil {
+			return nil, err
+		}
+		key, ok = k.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("jwt: unknown key id %q", kid)
+	}
+	if !key.matchAlg(token) {
+		return nil, fmt.Errorf("jwt: key %q is not for %s", kid, token.Method.Alg())
+	}
+	return key.key, nil
+}
+
+// Close stops the background refresh.
+func (k *JWKS) Close() error {
+	k.cancel()
+	return nil
+}
+
+func (k *JWKS) lookup(kid string) (*jwk, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+func (k *JWKS) refreshable() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return time.Since(k.lastAttempt) >= k.refreshLimit
+}
+
+// jwk is a JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	key interface{}
+}
+
+func (k *jwk) matchAlg(token *jwt.Token) bool {
+	return k.Alg == "" || k.Alg == token.Method.Alg()
+}
+
+func (k *jwk) parse() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 key size %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,270 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// jwksServer is a local stand-in of the jwks_uri of an OIDC provider.
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	keys     []map[string]string
+	failing  bool
+	requests int32
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&s.requests, 1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...map[string]string) {
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": encodeBigInt(key.N), "e": encodeBigInt(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": encodeBigInt(key.X), "y": encodeBigInt(key.Y),
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key crypto.PrivateKey, claims jwt.Claims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	str, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return str
+}
+
+func serverContext(token string) context.Context {
+	return transport.NewServerContext(context.Background(), &Transport{
+		reqHeader: newTokenHeader(authorizationKey, "Bearer "+token),
+	})
+}
+
+func TestJWKS(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("key1", key1), ecJWK("ec1", ecKey), map[string]string{"kty": "RSA", "kid": "enc", "use": "enc"})
+
+	jwks, err := NewJWKS(srv.URL, WithRefreshRateLimit(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jwks.Close()
+
+	var claims jwt.Claims
+	h := Server(jwks.Keyfunc, WithSigningMethods(jwt.SigningMethodRS256, jwt.SigningMethodES256))(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		claims, _ = FromContext(ctx)
+		return "reply", nil
+	})
+	tokens := []string{
+		signToken(t, jwt.SigningMethodRS256, "key1", key1, jwt.MapClaims{"sub": "rsa"}),
+		signToken(t, jwt.SigningMethodES256, "ec1", ecKey, jwt.MapClaims{"sub": "ec"}),
+		signToken(t, jwt.SigningMethodRS256, "", key1, jwt.MapClaims{"sub": "no-kid"}),
+	}
+	for _, token := range tokens {
+		if _, err = h(serverContext(token), nil); err != nil {
+			t.Fatalf("expect nil, got %v", err)
+		}
+	}
+	if sub, _ := claims.GetSubject(); sub != "no-kid" {
+		t.Errorf("expect %v, got %v", "no-kid", sub)
+	}
+
+	// a token signed by a key of another kid is rejected
+	forged := signToken(t, jwt.SigningMethodRS256, "key1", key2, jwt.MapClaims{"sub": "forged"})
+	if _, err = h(serverContext(forged), nil); !errors.Is(err, ErrTokenParseFail) {
+		t.Errorf("expect %v, got %v", ErrTokenParseFail, err)
+	}
+
+	// the rotated key is fetched on the unknown kid
+	srv.setKeys(rsaJWK("key2", key2))
+	rotated := signToken(t, jwt.SigningMethodRS256, "key2", key2, jwt.MapClaims{"sub": "rotated"})
+	if _, err = h(serverContext(rotated), nil); err != nil {
+		t.Errorf("expect nil, got %v", err)
+	}
+	if _, err = h(serverContext(tokens[0]), nil); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("expect %v, got %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestJWKSRefresh(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("key1", key))
+
+	jwks, err := NewJWKS(srv.URL, WithRefreshInterval(50*time.Millisecond), WithRefreshRateLimit(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jwks.Close()
+
+	// the unknown kids are rate limited
+	unknown := signToken(t, jwt.SigningMethodRS256, "missing", key, jwt.MapClaims{})
+	for i := 0; i < 10; i++ {
+		if _, err = jwt.Parse(unknown, jwks.Keyfunc); err == nil {
+			t.Fatal("expect unknown key error")
+		}
+	}
+	if n := atomic.LoadInt32(&srv.requests); n != 1 {
+		t.Errorf("expect %v request, got %v", 1, n)
+	}
+
+	srv.setKeys(rsaJWK("key2", key))
+	time.Sleep(200 * time.Millisecond)
+	token := signToken(t, jwt.SigningMethodRS256, "key2", key, jwt.MapClaims{})
+	if _, err = jwt.Parse(token, jwks.Keyfunc); err != nil {
+		t.Errorf("expect background refresh, got %v", err)
+	}
+
+	if _, err = NewJWKS(srv.URL + "/missing\x7f"); err == nil {
+		t.Error("expect invalid url error")
+	}
+}
+
+func TestJWKSRefreshFailure(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("key1", key))
+
+	jwks, err := NewJWKS(srv.URL, WithRefreshRateLimit(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jwks.Close()
+
+	time.Sleep(150 * time.Millisecond)
+	srv.mu.Lock()
+	srv.failing = true
+	srv.mu.Unlock()
+	// the failed refreshes are rate limited as well
+	unknown := signToken(t, jwt.SigningMethodRS256, "missing", key, jwt.MapClaims{})
+	for i := 0; i < 10; i++ {
+		if _, err = jwt.Parse(unknown, jwks.Keyfunc); err == nil {
+			t.Fatal("expect unknown key error")
+		}
+	}
+	if n := atomic.LoadInt32(&srv.requests); n != 2 {
+		t.Errorf("expect %v requests, got %v", 2, n)
+	}
+	token := signToken(t, jwt.SigningMethodRS256, "key1", key, jwt.MapClaims{})
+	if _, err = jwt.Parse(token, jwks.Keyfunc); err != nil {
+		t.Errorf("expect the cached keys kept, got %v", err)
+	}
+}
+
+func TestMultiIssuer(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	srv1, srv2 := newJWKSServer(t), newJWKSServer(t)
+	srv1.setKeys(rsaJWK("a", key1))
+	srv2.setKeys(ecJWK("b", key2))
+	jwks1, err := NewJWKS(srv1.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jwks1.Close()
+	jwks2, err := NewJWKS(srv2.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jwks2.Close()
+
+	keyFunc := MultiIssuer(
+		Issuer{Issuer: "https://accounts.example.com", Audience: []string{"api"}, Keyfunc: jwks1.Keyfunc},
+		Issuer{Issuer: "https://partner.example.com", Keyfunc: jwks2.Keyfunc},
+	)
+	h := Server(keyFunc, WithSigningMethods(jwt.SigningMethodRS256, jwt.SigningMethodES256))(func(context.Context, interface{}) (interface{}, error) {
+		return "reply", nil
+	})
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{
+			"issuer1",
+			signToken(t, jwt.SigningMethodRS256, "a", key1, jwt.MapClaims{"iss": "https://accounts.example.com", "aud": []string{"web", "api"}}),
+			nil,
+		},
+		{
+			"issuer2",
+			signToken(t, jwt.SigningMethodES256, "b", key2, jwt.MapClaims{"iss": "https://partner.example.com"}),
+			nil,
+		},
+		{
+			"wrong-audience",
+			signToken(t, jwt.SigningMethodRS256, "a", key1, jwt.MapClaims{"iss": "https://accounts.example.com", "aud": "web"}),
+			ErrTokenInvalid,
+		},
+		{
+			"untrusted-issuer",
+			signToken(t, jwt.SigningMethodRS256, "a", key1, jwt.MapClaims{"iss": "https://evil.example.com", "aud": "api"}),
+			ErrTokenInvalid,
+		},
+		{
+			"key-of-other-issuer",
+			signToken(t, jwt.SigningMethodES256, "b", key2, jwt.MapClaims{"iss": "https://accounts.example.com", "aud": "api"}),
+			ErrTokenInvalid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := h(serverContext(test.token), nil); !errors.Is(err, test.err) {
+				t.Errorf("expect %v, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -2,8 +2,6 @@ package jwt
 
 import (
 	"context"
-	"fmt"
-	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 
@@ -40,6 +38,7 @@ var (
 	ErrNeedTokenProvider      = errors.Unauthorized(reason, "Token provider is missing")
 	ErrSignToken              = errors.Unauthorized(reason, "Can not sign token.Is the key correct?")
 	ErrGetKey                 = errors.Unauthorized(reason, "Can not get key while signing token")
+	ErrGetToken               = errors.Unauthorized(reason, "Can not get token from token source")
 )
 
 // Option is jwt option.
@@ -47,9 +46,13 @@ type Option func(*options)
 
 // Parser is a jwt parser
 type options struct {
-	signingMethod jwt.SigningMethod
-	claims        func() jwt.Claims
-	tokenHeader   map[string]interface{}
+	signingMethod  jwt.SigningMethod
+	signingMethods []jwt.SigningMethod
+	claims         func() jwt.Claims
+	tokenHeader    map[string]interface{}
+	extractor      Extractor
+	injector       Injector
+	tokenSource    TokenSource
 }
 
 // WithSigningMethod with signing method option.
@@ -59,6 +62,14 @@ func WithSigningMethod(method jwt.SigningMethod) Option {
 	}
 }
 
+// WithSigningMethods with the signing methods accepted by the server,
+// e.g. the methods of the keys of multiple issuers.
+func WithSigningMethods(methods ...jwt.SigningMethod) Option {
+	return func(o *options) {
+		o.signingMethods = methods
+	}
+}
+
 // WithClaims with customer claim
 // If you use it in Server, f needs to return a new jwt.Claims object each time to avoid concurrent write problems
 // If you use it in Client, f only needs to return a single object to provide performance
@@ -75,10 +86,35 @@ func WithTokenHeader(header map[string]interface{}) Option {
 	}
 }
 
+// WithExtractor with the token extractor for server side,
+// default is the bearer token of the Authorization header.
+func WithExtractor(e Extractor) Option {
+	return func(o *options) {
+		o.extractor = e
+	}
+}
+
+// WithInjector with the token injector for client side,
+// default is the bearer token of the Authorization header.
+func WithInjector(i Injector) Option {
+	return func(o *options) {
+		o.injector = i
+	}
+}
+
+// WithTokenSource with the token source for client side, the tokens are taken
+// from it instead of being signed locally, and the keyProvider may be nil.
+func WithTokenSource(ts TokenSource) Option {
+	return func(o *options) {
+		o.tokenSource = ts
+	}
+}
+
 // Server is a server auth middleware. Check the token and extract the info from token.
 func Server(keyFunc jwt.Keyfunc, opts ...Option) middleware.Middleware {
 	o := &options{
 		signingMethod: jwt.SigningMethodHS256,
+		extractor:     FromHeader(authorizationKey, bearerWord),
 	}
 	for _, opt := range opts {
 		opt(o)
@@ -89,11 +125,10 @@ func Server(keyFunc jwt.Keyfunc, opts ...Option) middleware.Middleware {
 				if keyFunc == nil {
 					return nil, ErrMissingKeyFunc
 				}
-				auths := strings.SplitN(header.RequestHeader().Get(authorizationKey), " ", 2)
-				if len(auths) != 2 || !strings.EqualFold(auths[0], bearerWord) {
+				jwtToken, ok := o.extractor(header)
+				if !ok {
 					return nil, ErrMissingJwtToken
 				}
-				jwtToken := auths[1]
 				var (
 					tokenInfo *jwt.Token
 					err       error
@@ -116,7 +151,7 @@ func Server(keyFunc jwt.Keyfunc, opts ...Option) middleware.Middleware {
 				if !tokenInfo.Valid {
 					return nil, ErrTokenInvalid
 				}
-				if tokenInfo.Method != o.signingMethod {
+				if !o.acceptMethod(tokenInfo.Method) {
 					return nil, ErrUnSupportSigningMethod
 				}
 				ctx = NewContext(ctx, tokenInfo.Claims)
@@ -127,37 +162,44 @@ func Server(keyFunc jwt.Keyfunc, opts ...Option) middleware.Middleware {
 	}
 }
 
+func (o *options) acceptMethod(method jwt.SigningMethod) bool {
+	if len(o.signingMethods) == 0 {
+		return method == o.signingMethod
+	}
+	for _, m := range o.signingMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
 // Client is a client jwt middleware.
 func Client(keyProvider jwt.Keyfunc, opts ...Option) middleware.Middleware {
 	claims := jwt.RegisteredClaims{}
 	o := &options{
 		signingMethod: jwt.SigningMethodHS256,
 		claims:        func() jwt.Claims { return claims },
+		injector:      ToHeader(authorizationKey, bearerWord),
 	}
 	for _, opt := range opts {
 		opt(o)
 	}
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
-			if keyProvider == nil {
-				return nil, ErrNeedTokenProvider
-			}
-			token := jwt.NewWithClaims(o.signingMethod, o.claims())
-			if o.tokenHeader != nil {
-				for k, v := range o.tokenHeader {
-					token.Header[k] = v
+			var (
+				tokenStr string
+				err      error
+			)
+			if o.tokenSource != nil {
+				if tokenStr, err = o.tokenSource.Token(ctx); err != nil {
+					return nil, ErrGetToken.WithCause(err)
 				}
-			}
-			key, err := keyProvider(token)
-			if err != nil {
-				return nil, ErrGetKey
-			}
-			tokenStr, err := token.SignedString(key)
-			if err != nil {
-				return nil, ErrSignToken
+			} else if tokenStr, err = o.sign(keyProvider); err != nil {
+				return nil, err
 			}
 			if clientContext, ok := transport.FromClientContext(ctx); ok {
-				clientContext.RequestHeader().Set(authorizationKey, fmt.Sprintf(bearerFormat, tokenStr))
+				o.injector(clientContext, tokenStr)
 				return handler(ctx, req)
 			}
 			return nil, ErrWrongContext
@@ -165,6 +207,27 @@ func Client(keyProvider jwt.Keyfunc, opts ...Option) middleware.Middleware {
 	}
 }
 
+func (o *options) sign(keyProvider jwt.Keyfunc) (string, error) {
+	if keyProvider == nil {
+		return "", ErrNeedTokenProvider
+	}
+	token := jwt.NewWithClaims(o.signingMethod, o.claims())
+	if o.tokenHeader != nil {
+		for k, v := range o.tokenHeader {
+			token.Header[k] = v
+		}
+	}
+	key, err := keyProvider(token)
+	if err != nil {
+		return "", ErrGetKey
+	}
+	tokenStr, err := token.SignedString(key)
+	if err != nil {
+		return "", ErrSignToken
+	}
+	return tokenStr, nil
+}
+
 // NewContext put auth info into context
 func NewContext(ctx context.Context, info jwt.Claims) context.Context {
 	return context.WithValue(ctx, authKey{}, info)
@@ -175,3 +238,15 @@ func FromContext(ctx context.Context) (token jwt.Claims, ok bool) {
 	token, ok = ctx.Value(authKey{}).(jwt.Claims)
 	return
 }
+
+// SubjectFromContext extract the subject of the claims from context,
+// e.g. as the key of the per caller rate limit or cache.
+// It reports false if the claims have no or an invalid subject.
+func SubjectFromContext(ctx context.Context) (subject string, ok bool) {
+	claims, ok := FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	subject, err := claims.GetSubject()
+	return subject, err == nil && subject != ""
+}
@@ -523,3 +523,26 @@ func TestNewContextAndFromContext(t *testing.T) {
 		})
 	}
 }
+
+func TestSubjectFromContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		sub  string
+		ok   bool
+	}{
+		{"subject", NewContext(context.Background(), jwt.MapClaims{"sub": "alice"}), "alice", true},
+		{"registered claims", NewContext(context.Background(), &jwt.RegisteredClaims{Subject: "bob"}), "bob", true},
+		{"no subject", NewContext(context.Background(), jwt.MapClaims{}), "", false},
+		{"invalid subject", NewContext(context.Background(), jwt.MapClaims{"sub": 1}), "", false},
+		{"no claims", context.Background(), "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sub, ok := SubjectFromContext(test.ctx)
+			if sub != test.sub || ok != test.ok {
+				t.Errorf("expect %v %v, got %v %v", test.sub, test.ok, sub, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package jwt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenSource supplies the tokens of the client, e.g. from an identity provider,
+// instead of signing the tokens locally.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc is a function adapter of TokenSource.
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token implements TokenSource.
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// ReuseTokenSource returns a TokenSource reuses the token of src until it expires,
+// the token is refreshed early by the leeway. The expiry is read from the exp claim.
+func ReuseTokenSource(src TokenSource, leeway time.Duration) TokenSource {
+	return &reuseTokenSource{src: src, leeway: leeway}
+}
+
+type reuseTokenSource struct {
+	src    TokenSource
+	leeway time.Duration
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (s *reuseTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Add(s.leeway).Before(s.expiry) {
+		return s.token, nil
+	}
+	token, err := s.src.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiry = token, expiry(token)
+	return token, nil
+}
+
+// expiry returns the exp claim of the token, the token without exp is
+// treated as expired so it's never reused.
+func expiry(token string) time.Time {
+	claims := jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil || claims.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return claims.ExpiresAt.Time
+}
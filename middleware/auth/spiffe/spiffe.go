@@ -0,0 +1,90 @@
+package spiffe
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+var (
+	ErrMissingPeer  = errors.Unauthorized("UNAUTHORIZED", "SPIFFE ID of the peer certificate is missing")
+	ErrInvalidPeer  = errors.Unauthorized("UNAUTHORIZED", "Peer certificate has more than one URI SAN")
+	ErrForbidden    = errors.Forbidden("FORBIDDEN", "SPIFFE ID is not allowed")
+	ErrWrongContext = errors.Unauthorized("UNAUTHORIZED", "Wrong context for middleware")
+)
+
+// Option is spiffe option.
+type Option func(*options)
+
+type rule struct {
+	operation string
+	ids       []string
+}
+
+type options struct {
+	rules []rule
+}
+
+// Allow allows the callers of the SPIFFE IDs to call the operation.
+// An operation ending with '*' matches the prefix, e.g. '/*' or '/helloworld.v1.Greeter/*',
+// and an ID ending with '*' matches the prefix, e.g. 'spiffe://example.org/ns/prod/*'.
+func Allow(operation string, ids ...string) Option {
+	return func(o *options) {
+		o.rules = append(o.rules, rule{operation: operation, ids: ids})
+	}
+}
+
+// Server is a server middleware allow-lists the callers by the SPIFFE ID of the
+// verified client certificate, the operations without any allow rule are denied.
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			peer, ok := transport.PeerFromServerContext(ctx)
+			if !ok {
+				return nil, ErrMissingPeer
+			}
+			if len(peer.URIs) > 1 {
+				return nil, ErrInvalidPeer
+			}
+			if peer.SPIFFEID == "" {
+				return nil, ErrMissingPeer
+			}
+			if !o.allowed(tr.Operation(), peer.SPIFFEID) {
+				return nil, ErrForbidden
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+func (o *options) allowed(operation, id string) bool {
+	for _, r := range o.rules {
+		if !match(r.operation, operation) {
+			continue
+		}
+		for _, pattern := range r.ids {
+			if match(pattern, id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func match(pattern, s string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(s, prefix)
+	}
+	return pattern == s
+}
@@ -0,0 +1,256 @@
+package spiffe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	pb "github.com/go-kratos/kratos/v2/internal/testdata/helloworld"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+func (ca *testCA) issue(t *testing.T, id string, usage x509.ExtKeyUsage) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "workload", Organization: []string{"kratos"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{"localhost"},
+	}
+	// the ids separated by spaces are the URI SANs
+	for _, id := range strings.Fields(id) {
+		u, _ := url.Parse(id)
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (ca *testCA) serverTLS(t *testing.T) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{ca.issue(t, "spiffe://example.org/ns/prod/sa/server", x509.ExtKeyUsageServerAuth)},
+		ClientCAs:    ca.pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+func (ca *testCA) clientTLS(t *testing.T, id string) *tls.Config {
+	c := &tls.Config{RootCAs: ca.pool, ServerName: "localhost", MinVersion: tls.VersionTLS12}
+	if id != "-" {
+		c.Certificates = []tls.Certificate{ca.issue(t, id, x509.ExtKeyUsageClientAuth)}
+	}
+	return c
+}
+
+func TestHTTP(t *testing.T) {
+	ca := newCA(t)
+	srv := khttp.NewServer(khttp.Middleware(Server(
+		Allow("/hello*", "spiffe://example.org/ns/prod/*"),
+		Allow("/hello", "spiffe://example.org/ns/staging/sa/tester"),
+	)))
+	srv.Route("/").GET("/hello", func(ctx khttp.Context) error {
+		h := ctx.Middleware(func(ctx context.Context, _ interface{}) (interface{}, error) {
+			peer, _ := transport.PeerFromServerContext(ctx)
+			return peer.SPIFFEID, nil
+		})
+		reply, err := h(ctx, nil)
+		if err != nil {
+			return err
+		}
+		return ctx.String(http.StatusOK, reply.(string))
+	})
+	ts := httptest.NewUnstartedServer(srv)
+	ts.TLS = ca.serverTLS(t)
+	ts.StartTLS()
+	defer ts.Close()
+
+	tests := []struct {
+		id     string
+		status int
+	}{
+		{"spiffe://example.org/ns/prod/sa/client", http.StatusOK},
+		{"spiffe://example.org/ns/staging/sa/tester", http.StatusOK},
+		{"spiffe://example.org/ns/dev/sa/client", http.StatusForbidden},
+		{"spiffe://example.org/ns/prod/sa/client spiffe://example.org/ns/dev/sa/client", http.StatusUnauthorized},
+		{"", http.StatusUnauthorized},
+		{"-", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: ca.clientTLS(t, test.id)}}
+		resp, err := client.Get(ts.URL + "/hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("%s: expect %v, got %v", test.id, test.status, resp.StatusCode)
+		}
+	}
+}
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	peer, _ := transport.PeerFromServerContext(ctx)
+	return &pb.HelloReply{Message: peer.SPIFFEID}, nil
+}
+
+func TestGRPC(t *testing.T) {
+	ca := newCA(t)
+	srv := grpc.NewServer(
+		grpc.Address("127.0.0.1:0"),
+		grpc.TLSConfig(ca.serverTLS(t)),
+		grpc.Middleware(Server(Allow("/helloworld.Greeter/SayHello", "spiffe://example.org/ns/prod/sa/client"))),
+	)
+	pb.RegisterGreeterServer(srv, greeter{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() {
+		_ = srv.Stop(context.Background())
+	}()
+
+	tests := []struct {
+		id  string
+		err error
+	}{
+		{"spiffe://example.org/ns/prod/sa/client", nil},
+		{"spiffe://example.org/ns/prod/sa/other", ErrForbidden},
+		{"-", ErrMissingPeer},
+	}
+	for _, test := range tests {
+		conn, err := grpc.Dial(context.Background(), grpc.WithEndpoint(u.Host), grpc.WithTLSConfig(ca.clientTLS(t, test.id)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reply, err := pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "kratos"})
+		if err != nil {
+			err = kerrors.FromError(err)
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expect %v, got %v", test.id, test.err, err)
+		}
+		if err == nil && reply.Message != test.id {
+			t.Errorf("expect %v, got %v", test.id, reply.Message)
+		}
+		_ = conn.Close()
+	}
+}
+
+func TestCustomVerification(t *testing.T) {
+	ca := newCA(t)
+	conf := ca.serverTLS(t)
+	// the SPIFFE workloads verify the certificates themselves instead of by the client CAs
+	conf.ClientCAs, conf.ClientAuth = nil, tls.RequireAnyClientCert
+	conf.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		_, err = cert.Verify(x509.VerifyOptions{Roots: ca.pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+		return err
+	}
+	srv := grpc.NewServer(
+		grpc.Address("127.0.0.1:0"),
+		grpc.TLSConfig(conf),
+		grpc.Middleware(Server(Allow("/helloworld.Greeter/SayHello", "spiffe://example.org/ns/prod/sa/client"))),
+	)
+	pb.RegisterGreeterServer(srv, greeter{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() {
+		_ = srv.Stop(context.Background())
+	}()
+
+	tests := []struct {
+		id  string
+		err error
+	}{
+		{"spiffe://example.org/ns/prod/sa/client", nil},
+		{"spiffe://example.org/ns/prod/sa/client spiffe://example.org/ns/prod/sa/other", ErrInvalidPeer},
+	}
+	for _, test := range tests {
+		conn, err := grpc.Dial(context.Background(), grpc.WithEndpoint(u.Host), grpc.WithTLSConfig(ca.clientTLS(t, test.id)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reply, err := pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "kratos"})
+		if err != nil {
+			err = kerrors.FromError(err)
+		}
+		// the missing and the invalid peers are both unauthorized
+		if !errors.Is(err, test.err) || (err != nil && err.(*kerrors.Error).Message != test.err.(*kerrors.Error).Message) {
+			t.Errorf("%s: expect %v, got %v", test.id, test.err, err)
+		}
+		if err == nil && reply.Message != test.id {
+			t.Errorf("expect %v, got %v", test.id, reply.Message)
+		}
+		_ = conn.Close()
+	}
+}
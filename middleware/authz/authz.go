@@ -0,0 +1,171 @@
+package authz
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	jwtauth "github.com/go-kratos/kratos/v2/middleware/auth/jwt"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// DefaultRolesClaim is the default jwt claim of the subject roles.
+const DefaultRolesClaim = "roles"
+
+const reason string = "FORBIDDEN"
+
+var (
+	ErrMissingSubject = errors.Unauthorized("UNAUTHORIZED", "Subject is missing")
+	ErrForbidden      = errors.Forbidden(reason, "Permission denied")
+	ErrWrongContext   = errors.Forbidden(reason, "Wrong context for middleware")
+	ErrAuthorizer     = errors.InternalServer("AUTHORIZER", "Authorizer failed")
+)
+
+type subjectKey struct{}
+
+// Subject is the authenticated principal of a request.
+type Subject struct {
+	// ID is the subject identity, e.g. the user id.
+	ID string
+	// Roles is the roles granted to the subject.
+	Roles []string
+	// Attributes is the subject attributes for attribute-based policies.
+	Attributes map[string]interface{}
+}
+
+// Request is an authorization request.
+type Request struct {
+	Subject   *Subject
+	Kind      transport.Kind
+	Operation string
+	Header    transport.Header
+	// Args is the request message.
+	Args interface{}
+}
+
+// Authorizer is a policy engine decides whether the request is allowed,
+// external engines such as Casbin or an in-process rego evaluator implement it.
+// An error of the engine fails the request with ErrAuthorizer rather than a deny.
+type Authorizer interface {
+	Authorize(ctx context.Context, req *Request) (bool, error)
+}
+
+// AuthorizerFunc is a function adapter of Authorizer.
+type AuthorizerFunc func(ctx context.Context, req *Request) (bool, error)
+
+// Authorize implements Authorizer.
+func (f AuthorizerFunc) Authorize(ctx context.Context, req *Request) (bool, error) {
+	return f(ctx, req)
+}
+
+// SubjectFunc returns the subject of the authenticated context.
+type SubjectFunc func(ctx context.Context) (*Subject, bool)
+
+// Option is authz option.
+type Option func(*options)
+
+type options struct {
+	subject SubjectFunc
+}
+
+// WithSubject with the func extracts the subject from the authenticated context,
+// default is the subject in context, then JWTSubject(DefaultRolesClaim).
+func WithSubject(f SubjectFunc) Option {
+	return func(o *options) {
+		o.subject = f
+	}
+}
+
+// Server is an authorization middleware, it must be placed after the authentication.
+func Server(authorizer Authorizer, opts ...Option) middleware.Middleware {
+	o := &options{
+		subject: defaultSubject,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			subject, ok := o.subject(ctx)
+			if !ok {
+				return nil, ErrMissingSubject
+			}
+			allowed, err := authorizer.Authorize(ctx, &Request{
+				Subject:   subject,
+				Kind:      tr.Kind(),
+				Operation: tr.Operation(),
+				Header:    tr.RequestHeader(),
+				Args:      req,
+			})
+			if err != nil {
+				return nil, ErrAuthorizer.WithCause(err)
+			}
+			if !allowed {
+				return nil, ErrForbidden
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// NewContext put the subject into context, for the authentication
+// middleware other than jwt.
+func NewContext(ctx context.Context, subject *Subject) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// FromContext extract the subject from context.
+func FromContext(ctx context.Context) (subject *Subject, ok bool) {
+	subject, ok = ctx.Value(subjectKey{}).(*Subject)
+	return
+}
+
+func defaultSubject(ctx context.Context) (*Subject, bool) {
+	if subject, ok := FromContext(ctx); ok {
+		return subject, true
+	}
+	return JWTSubject(DefaultRolesClaim)(ctx)
+}
+
+// JWTSubject returns a SubjectFunc reads the subject from the jwt claims,
+// the roles are read from the claim of a string list or a space-separated string.
+func JWTSubject(rolesClaim string) SubjectFunc {
+	return func(ctx context.Context) (*Subject, bool) {
+		claims, ok := jwtauth.FromContext(ctx)
+		if !ok {
+			return nil, false
+		}
+		subject := &Subject{}
+		subject.ID, _ = claims.GetSubject()
+		if mc, ok := claims.(jwt.MapClaims); ok {
+			subject.Roles = roles(mc[rolesClaim])
+			subject.Attributes = mc
+		}
+		return subject, true
+	}
+}
+
+func roles(v interface{}) []string {
+	switch roles := v.(type) {
+	case string:
+		return strings.Fields(roles)
+	case []string:
+		return roles
+	case []interface{}:
+		res := make([]string, 0, len(roles))
+		for _, role := range roles {
+			if s, ok := role.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
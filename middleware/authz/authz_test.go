@@ -0,0 +1,171 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/file"
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	jwtauth "github.com/go-kratos/kratos/v2/middleware/auth/jwt"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string  { return hc[key] }
+func (hc headerCarrier) Set(key, value string)  { hc[key] = value }
+func (hc headerCarrier) Add(key, value string)  { hc[key] = value }
+func (hc headerCarrier) Keys() []string         { return nil }
+func (hc headerCarrier) Values(string) []string { return nil }
+
+type Transport struct {
+	operation string
+}
+
+func (tr *Transport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *Transport) Endpoint() string                { return "" }
+func (tr *Transport) Operation() string               { return tr.operation }
+func (tr *Transport) RequestHeader() transport.Header { return headerCarrier{} }
+func (tr *Transport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func newContext(operation string, claims jwt.Claims) context.Context {
+	ctx := transport.NewServerContext(context.Background(), &Transport{operation: operation})
+	if claims != nil {
+		ctx = jwtauth.NewContext(ctx, claims)
+	}
+	return ctx
+}
+
+func handler(context.Context, interface{}) (interface{}, error) {
+	return "reply", nil
+}
+
+func TestRBAC(t *testing.T) {
+	rbac, err := NewRBAC(
+		Role{Name: "viewer", Operations: []string{"/blog.v1.Blog/Get*", "/blog.v1.Blog/ListArticles"}},
+		Role{Name: "editor", Operations: []string{"/blog.v1.Blog/UpdateArticle"}, Inherits: []string{"viewer"}},
+		Role{Name: "admin", Operations: []string{"/*"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := Server(rbac)(handler)
+
+	tests := []struct {
+		name      string
+		operation string
+		claims    jwt.Claims
+		err       error
+	}{
+		{"viewer-get", "/blog.v1.Blog/GetArticle", jwt.MapClaims{"sub": "u1", "roles": []interface{}{"viewer"}}, nil},
+		{"viewer-update", "/blog.v1.Blog/UpdateArticle", jwt.MapClaims{"sub": "u1", "roles": "viewer"}, ErrForbidden},
+		{"editor-inherited", "/blog.v1.Blog/ListArticles", jwt.MapClaims{"sub": "u2", "roles": "editor"}, nil},
+		{"editor-update", "/blog.v1.Blog/UpdateArticle", jwt.MapClaims{"sub": "u2", "roles": "editor"}, nil},
+		{"admin", "/blog.v1.Blog/DeleteArticle", jwt.MapClaims{"sub": "u3", "roles": "guest admin"}, nil},
+		{"no-roles", "/blog.v1.Blog/GetArticle", &jwt.RegisteredClaims{Subject: "u4"}, ErrForbidden},
+		{"no-subject", "/blog.v1.Blog/GetArticle", nil, ErrMissingSubject},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := h(newContext(test.operation, test.claims), nil)
+			if !errors.Is(err, test.err) {
+				t.Errorf("expect %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestInvalidRoles(t *testing.T) {
+	if _, err := NewRBAC(Role{Name: "a", Inherits: []string{"missing"}}); err == nil {
+		t.Error("expect unknown role error")
+	}
+	if _, err := NewRBAC(Role{Name: "a"}, Role{Name: "a"}); err == nil {
+		t.Error("expect duplicate role error")
+	}
+	if _, err := NewRBAC(Role{Name: "a", Inherits: []string{"b"}}, Role{Name: "b", Inherits: []string{"a"}}); err != nil {
+		t.Errorf("expect cycle allowed, got %v", err)
+	}
+}
+
+func TestAuthorizerFunc(t *testing.T) {
+	// attribute-based policy: the tenant of the subject must match the request header
+	abac := AuthorizerFunc(func(_ context.Context, req *Request) (bool, error) {
+		return req.Subject.Attributes["tenant"] == "acme", nil
+	})
+	ctx := NewContext(
+		transport.NewServerContext(context.Background(), &Transport{operation: "/test"}),
+		&Subject{ID: "u1", Attributes: map[string]interface{}{"tenant": "acme"}},
+	)
+	if _, err := Server(abac)(handler)(ctx, nil); err != nil {
+		t.Errorf("expect nil, got %v", err)
+	}
+	ctx = NewContext(ctx, &Subject{ID: "u2", Attributes: map[string]interface{}{"tenant": "other"}})
+	if _, err := Server(abac)(handler)(ctx, nil); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expect %v, got %v", ErrForbidden, err)
+	}
+
+	engineErr := errors.New("engine unavailable")
+	failing := AuthorizerFunc(func(context.Context, *Request) (bool, error) {
+		return false, engineErr
+	})
+	_, err := Server(failing)(handler)(ctx, nil)
+	if !kerrors.IsInternalServer(err) || !errors.Is(err, engineErr) {
+		t.Errorf("expect internal server error caused by %v, got %v", engineErr, err)
+	}
+	if _, err = Server(abac)(handler)(context.Background(), nil); !errors.Is(err, ErrWrongContext) {
+		t.Errorf("expect %v, got %v", ErrWrongContext, err)
+	}
+}
+
+const (
+	testRoles = `{"authz": {"roles": [{"name": "viewer", "operations": ["/blog.v1.Blog/Get*"]}]}}`
+	testNext  = `{"authz": {"roles": [{"name": "viewer", "operations": ["/blog.v1.Blog/List*"]}]}}`
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authz.json")
+	if err := os.WriteFile(path, []byte(testRoles), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := config.New(config.WithSource(file.NewSource(path)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	rbac, err := NewRBAC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rbac.Watch(c, "authz.roles"); err != nil {
+		t.Fatal(err)
+	}
+	h := Server(rbac)(handler)
+	claims := jwt.MapClaims{"sub": "u1", "roles": "viewer"}
+	if _, err = h(newContext("/blog.v1.Blog/GetArticle", claims), nil); err != nil {
+		t.Fatalf("expect nil, got %v", err)
+	}
+
+	if err = os.WriteFile(path, []byte(testNext), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if roles := rbac.Roles(); len(roles) == 1 && roles[0].Operations[0] == "/blog.v1.Blog/List*" {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if _, err = h(newContext("/blog.v1.Blog/GetArticle", claims), nil); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expect %v, got %v", ErrForbidden, err)
+	}
+	if _, err = h(newContext("/blog.v1.Blog/ListArticles", claims), nil); err != nil {
+		t.Errorf("expect nil, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+var _ Authorizer = (*RBAC)(nil)
+
+// Role is a role with the operations it is permitted to call.
+type Role struct {
+	// Name is the role name.
+	Name string `json:"name"`
+	// Operations is the permitted operations, an operation ending with '*'
+	// matches the prefix, e.g. '/*' or '/helloworld.v1.Greeter/*'.
+	Operations []string `json:"operations"`
+	// Inherits is the roles whose operations are also permitted.
+	Inherits []string `json:"inherits"`
+}
+
+// RBAC is a role-based access control authorizer.
+type RBAC struct {
+	roles atomic.Value
+}
+
+type rbacRoles struct {
+	roles []Role
+	// operations is the resolved operations of the roles including the inherited ones.
+	operations map[string][]string
+}
+
+// NewRBAC new a role-based access control authorizer with roles.
+func NewRBAC(roles ...Role) (*RBAC, error) {
+	r := &RBAC{}
+	if err := r.Update(roles); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update replaces the roles.
+func (r *RBAC) Update(roles []Role) error {
+	byName := make(map[string]Role, len(roles))
+	for _, role := range roles {
+		if role.Name == "" {
+			return fmt.Errorf("authz: role without name")
+		}
+		if _, ok := byName[role.Name]; ok {
+			return fmt.Errorf("authz: duplicate role %q", role.Name)
+		}
+		byName[role.Name] = role
+	}
+	operations := make(map[string][]string, len(roles))
+	for _, role := range roles {
+		visited := make(map[string]struct{})
+		ops, err := resolve(byName, role.Name, visited)
+		if err != nil {
+			return err
+		}
+		operations[role.Name] = ops
+	}
+	r.roles.Store(&rbacRoles{roles: roles, operations: operations})
+	return nil
+}
+
+func resolve(roles map[string]Role, name string, visited map[string]struct{}) ([]string, error) {
+	if _, ok := visited[name]; ok {
+		// inheritance cycle, the operations are already collected
+		return nil, nil
+	}
+	visited[name] = struct{}{}
+	role, ok := roles[name]
+	if !ok {
+		return nil, fmt.Errorf("authz: unknown inherited role %q", name)
+	}
+	ops := append([]string{}, role.Operations...)
+	for _, parent := range role.Inherits {
+		inherited, err := resolve(roles, parent, visited)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, inherited...)
+	}
+	return ops, nil
+}
+
+// Roles returns the current roles.
+func (r *RBAC) Roles() []Role {
+	if roles, ok := r.roles.Load().(*rbacRoles); ok {
+		return roles.roles
+	}
+	return nil
+}
+
+// Watch loads the roles from the config key and reloads them on change.
+func (r *RBAC) Watch(c config.Config, key string) error {
+	var roles []Role
+	if err := c.Value(key).Scan(&roles); err != nil {
+		return err
+	}
+	if err := r.Update(roles); err != nil {
+		return err
+	}
+	return c.Watch(key, func(_ string, v config.Value) {
+		var roles []Role
+		if err := v.Scan(&roles); err != nil {
+			log.Errorf("authz: failed to scan roles: %v", err)
+			return
+		}
+		if err := r.Update(roles); err != nil {
+			log.Errorf("authz: failed to update roles: %v", err)
+		}
+	})
+}
+
+// Authorize implements Authorizer, the request is allowed if any role of
+// the subject permits the operation.
+func (r *RBAC) Authorize(_ context.Context, req *Request) (bool, error) {
+	roles, _ := r.roles.Load().(*rbacRoles)
+	if roles == nil || req.Subject == nil {
+		return false, nil
+	}
+	for _, role := range req.Subject.Roles {
+		for _, op := range roles.operations[role] {
+			if matchOperation(op, req.Operation) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func matchOperation(pattern, operation string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(operation, prefix)
+	}
+	return pattern == operation
+}
@@ -0,0 +1,330 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	ic "github.com/go-kratos/kratos/v2/internal/context"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// StatusHeader is the reply header of the cache status: HIT, STALE or MISS.
+const StatusHeader = "X-Cache"
+
+const (
+	statusHit   = "HIT"
+	statusStale = "STALE"
+	statusMiss  = "MISS"
+)
+
+// KeyFunc returns the scope of the cached reply, e.g. the user ID,
+// ok is false if the request can't be scoped and bypasses the cache.
+type KeyFunc func(ctx context.Context) (key string, ok bool)
+
+// Option is cache option.
+type Option func(*options)
+
+type options struct {
+	store   Store
+	key     KeyFunc
+	ttl     time.Duration
+	stale   time.Duration
+	timeout time.Duration
+}
+
+// WithStore with the store of the replies, default is an in-memory LRU store of 10000 replies.
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithKey with the scope of the replies, the replies are shared by all the callers by default.
+// The replies of a scoped cache are marked private on HTTP.
+// e.g. WithKey(func(ctx context.Context) (string, bool) { claims, ok := jwt.FromContext(ctx) ... })
+func WithKey(key KeyFunc) Option {
+	return func(o *options) {
+		o.key = key
+	}
+}
+
+// WithTTL with the freshness of the replies, default is 1m.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithStaleWhileRevalidate with the duration the stale replies are served after the TTL,
+// while the reply is refreshed in background. The refresh runs with a new context carries only
+// the metadata, the trace and a copy of the request headers of the request triggers it.
+func WithStaleWhileRevalidate(d time.Duration) Option {
+	return func(o *options) {
+		o.stale = d
+	}
+}
+
+// WithLoadTimeout with the timeout of the handler calls shared by the concurrent misses
+// and the background refreshes, default is 10s.
+func WithLoadTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// Server is a server middleware caches the replies of the idempotent reads, keyed by
+// the operation and the deterministic encoding of the request. The requests and the replies
+// must be proto messages, the errors are never cached. The handler runs with a copy of the transport
+// on the misses, so the reply headers it sets are dropped. The HTTP replies whose ETag matches
+// If-None-Match are written with 304 Not Modified and no body, see khttp.SetReplyStatus.
+// e.g. selector.Server(cache.Server()).Path("/blog.v1.Blog/GetArticle").Build()
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{ttl: time.Minute, timeout: 10 * time.Second}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.store == nil {
+		o.store = NewMemoryStore(10000) //nolint:gomnd
+	}
+	c := &cache{options: o}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			m, ok := req.(proto.Message)
+			if !ok {
+				return handler(ctx, req)
+			}
+			var scope string
+			if c.key != nil {
+				if scope, ok = c.key(ctx); !ok {
+					return handler(ctx, req)
+				}
+			}
+			key, err := requestKey(tr.Operation(), scope, m)
+			if err != nil {
+				return handler(ctx, req)
+			}
+			isHTTP := tr.Kind() == transport.KindHTTP
+			var entry *Entry
+			if !isHTTP || !noCache(tr.RequestHeader().Get("Cache-Control")) {
+				if entry, err = c.store.Get(ctx, key); err != nil {
+					log.Context(ctx).Errorf("cache: failed to get the reply of %s: %v", tr.Operation(), err)
+				}
+			}
+			status := statusHit
+			switch age := time.Since(entry.storedAt()); {
+			case entry == nil || age >= c.ttl+c.stale:
+				status = statusMiss
+				if entry, err = c.load(ctx, tr, key, req, handler); err != nil {
+					return nil, err
+				}
+			case age >= c.ttl:
+				status = statusStale
+				c.revalidate(ctx, tr, key, req, handler)
+			}
+			if isHTTP {
+				header := tr.ReplyHeader()
+				header.Set(StatusHeader, status)
+				header.Set("ETag", entry.ETag)
+				header.Set("Cache-Control", c.cacheControl(entry))
+				if etagMatch(tr.RequestHeader().Get("If-None-Match"), entry.ETag) {
+					// the reply is written without the body
+					khttp.SetReplyStatus(ctx, http.StatusNotModified)
+				}
+			}
+			return entry.Reply.UnmarshalNew()
+		}
+	}
+}
+
+type cache struct {
+	*options
+	group      singleflight.Group
+	refreshing sync.Map
+}
+
+// revalidate refreshes the stale reply of the key in background, at most one refresh of the key at a time.
+func (c *cache) revalidate(ctx context.Context, tr transport.Transporter, key string, req interface{}, handler middleware.Handler) {
+	if _, loaded := c.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	// the refresh outlives the request, so it carries none of the values of the completed request
+	bg := context.Background()
+	if md, ok := metadata.FromServerContext(ctx); ok {
+		bg = metadata.NewServerContext(bg, md.Clone())
+	}
+	bg = trace.ContextWithSpanContext(bg, trace.SpanContextFromContext(ctx))
+	bg = transport.NewServerContext(bg, detach(tr))
+	go func() {
+		defer c.refreshing.Delete(key)
+		if entry, _ := c.store.Get(bg, key); entry != nil && time.Since(entry.StoredAt) < c.ttl {
+			return
+		}
+		if _, err := c.load(bg, nil, key, req, handler); err != nil {
+			log.Context(bg).Warnf("cache: failed to revalidate the reply: %v", err)
+		}
+	}()
+}
+
+// load calls the handler and stores the reply, the concurrent loads of the key are collapsed.
+// The shared call is detached from the callers, so a canceled caller doesn't fail the others.
+func (c *cache) load(ctx context.Context, tr transport.Transporter, key string, req interface{}, handler middleware.Handler) (*Entry, error) {
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		lctx := ic.Detach(ctx)
+		if tr != nil {
+			lctx = transport.NewServerContext(lctx, detach(tr))
+		}
+		lctx, cancel := context.WithTimeout(lctx, c.timeout)
+		defer cancel()
+		reply, err := handler(lctx, req)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := reply.(proto.Message)
+		if !ok {
+			return nil, fmt.Errorf("cache: reply %T is not a proto message", reply)
+		}
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		replyAny, err := anypb.New(m)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(b)
+		entry := &Entry{
+			Reply:    replyAny,
+			ETag:     `"` + hex.EncodeToString(sum[:16]) + `"`,
+			StoredAt: time.Now(),
+		}
+		if err = c.store.Set(lctx, key, entry, c.ttl+c.stale); err != nil {
+			log.Context(lctx).Errorf("cache: failed to set the reply: %v", err)
+		}
+		return entry, nil
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*Entry), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *cache) cacheControl(entry *Entry) string {
+	maxAge := c.ttl - time.Since(entry.StoredAt)
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	cc := fmt.Sprintf("max-age=%d", int64(maxAge.Seconds()))
+	if c.key != nil {
+		cc = "private, " + cc
+	}
+	if c.stale > 0 {
+		cc += fmt.Sprintf(", stale-while-revalidate=%d", int64(c.stale.Seconds()))
+	}
+	return cc
+}
+
+// requestKey returns the key of the operation, the scope and the request.
+func requestKey(operation, scope string, req proto.Message) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(scope))
+	h.Write([]byte{0})
+	h.Write(b)
+	return operation + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// noCache reports whether the Cache-Control request header bypasses the cache.
+func noCache(cacheControl string) bool {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		switch strings.TrimSpace(strings.ToLower(directive)) {
+		case "no-cache", "no-store":
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatch reports whether the If-None-Match request header matches the ETag.
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// detachedTransport is the copy of the server transport for the calls outliving the request,
+// the reply headers are discarded.
+type detachedTransport struct {
+	kind        transport.Kind
+	endpoint    string
+	operation   string
+	reqHeader   detachedHeader
+	replyHeader detachedHeader
+}
+
+func detach(tr transport.Transporter) *detachedTransport {
+	header := detachedHeader{}
+	for _, k := range tr.RequestHeader().Keys() {
+		for _, v := range tr.RequestHeader().Values(k) {
+			header.Add(k, v)
+		}
+	}
+	return &detachedTransport{
+		kind:        tr.Kind(),
+		endpoint:    tr.Endpoint(),
+		operation:   tr.Operation(),
+		reqHeader:   header,
+		replyHeader: detachedHeader{},
+	}
+}
+
+func (tr *detachedTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *detachedTransport) Endpoint() string                { return tr.endpoint }
+func (tr *detachedTransport) Operation() string               { return tr.operation }
+func (tr *detachedTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *detachedTransport) ReplyHeader() transport.Header   { return tr.replyHeader }
+
+type detachedHeader http.Header
+
+func (hc detachedHeader) Get(key string) string      { return http.Header(hc).Get(key) }
+func (hc detachedHeader) Set(key, value string)      { http.Header(hc).Set(key, value) }
+func (hc detachedHeader) Add(key, value string)      { http.Header(hc).Add(key, value) }
+func (hc detachedHeader) Values(key string) []string { return http.Header(hc).Values(key) }
+
+func (hc detachedHeader) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	pb "github.com/go-kratos/kratos/v2/internal/testdata/helloworld"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+func (hc headerCarrier) Add(key, value string) { hc[key] = value }
+func (hc headerCarrier) Values(key string) []string {
+	if v, ok := hc[key]; ok {
+		return []string{v}
+	}
+	return nil
+}
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type Transport struct {
+	header headerCarrier
+}
+
+func (tr *Transport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *Transport) Endpoint() string                { return "" }
+func (tr *Transport) Operation() string               { return "/blog.v1.Blog/GetArticle" }
+func (tr *Transport) RequestHeader() transport.Header { return tr.header }
+func (tr *Transport) ReplyHeader() transport.Header   { return tr.header }
+
+func newContext() context.Context {
+	return transport.NewServerContext(context.Background(), &Transport{header: headerCarrier{}})
+}
+
+func TestServer(t *testing.T) {
+	var calls int32
+	h := Server(WithTTL(100*time.Millisecond), WithStaleWhileRevalidate(time.Second))(
+		func(_ context.Context, req interface{}) (interface{}, error) {
+			n := atomic.AddInt32(&calls, 1)
+			return wrapperspb.String(fmt.Sprintf("%s #%d", req.(*wrapperspb.StringValue).Value, n)), nil
+		})
+	get := func(id string) string {
+		reply, err := h(newContext(), wrapperspb.String(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply.(*wrapperspb.StringValue).Value
+	}
+	if reply := get("a"); reply != "a #1" {
+		t.Errorf("expect %v, got %v", "a #1", reply)
+	}
+	if reply := get("a"); reply != "a #1" {
+		t.Errorf("expect the cached reply, got %v", reply)
+	}
+	if reply := get("b"); reply != "b #2" {
+		t.Errorf("expect %v, got %v", "b #2", reply)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if reply := get("a"); reply != "a #1" {
+		t.Errorf("expect the stale reply, got %v", reply)
+	}
+	for i := 0; i < 50 && get("a") == "a #1"; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if reply := get("a"); reply != "a #3" {
+		t.Errorf("expect the revalidated reply, got %v", reply)
+	}
+}
+
+func TestSingleflight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	h := Server()(func(context.Context, interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return wrapperspb.String("reply"), nil
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := h(newContext(), wrapperspb.String("a")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("expect the concurrent misses collapsed, got %v calls", calls)
+	}
+}
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+	calls int32
+}
+
+func (g *greeter) SayHello(_ context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	n := atomic.AddInt32(&g.calls, 1)
+	return &pb.HelloReply{Message: fmt.Sprintf("hello %s #%d", in.Name, n)}, nil
+}
+
+func TestHTTP(t *testing.T) {
+	g := &greeter{}
+	var errs []error
+	srv := khttp.NewServer(khttp.Middleware(
+		func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				reply, err := handler(ctx, req)
+				errs = append(errs, err)
+				return reply, err
+			}
+		},
+		Server(WithTTL(time.Minute)),
+	))
+	pb.RegisterGreeterHTTPServer(srv, g)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	get := func(header http.Header) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/helloworld/kratos", nil)
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	resp := get(http.Header{})
+	etag := resp.Header.Get("ETag")
+	if resp.Header.Get(StatusHeader) != statusMiss || etag == "" || !strings.HasPrefix(resp.Header.Get("Cache-Control"), "max-age=") {
+		t.Errorf("unexpected headers %v", resp.Header)
+	}
+	resp = get(http.Header{"If-None-Match": []string{etag}})
+	if resp.StatusCode != http.StatusNotModified || resp.Header.Get(StatusHeader) != statusHit {
+		t.Errorf("expect %v, got %v %v", http.StatusNotModified, resp.StatusCode, resp.Header)
+	}
+	resp = get(http.Header{"Cache-Control": []string{"no-cache"}})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(StatusHeader) != statusMiss || resp.Header.Get("ETag") == etag {
+		t.Errorf("expect the cache bypassed, got %v %v", resp.StatusCode, resp.Header)
+	}
+	if g.calls != 2 {
+		t.Errorf("expect %v calls, got %v", 2, g.calls)
+	}
+	// the not modified reply isn't an error
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("expect no error, got %v", err)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	var calls int32
+	h := Server(WithKey(func(ctx context.Context) (string, bool) {
+		tr, _ := transport.FromServerContext(ctx)
+		user := tr.RequestHeader().Get("X-User")
+		return user, user != ""
+	}))(func(context.Context, interface{}) (interface{}, error) {
+		return wrapperspb.String(fmt.Sprintf("#%d", atomic.AddInt32(&calls, 1))), nil
+	})
+	get := func(user string) string {
+		ctx := transport.NewServerContext(context.Background(), &Transport{header: headerCarrier{"X-User": user}})
+		reply, err := h(ctx, wrapperspb.String("a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply.(*wrapperspb.StringValue).Value
+	}
+	for _, test := range []struct{ user, reply string }{
+		{"alice", "#1"},
+		{"bob", "#2"},
+		{"alice", "#1"},
+		{"", "#3"},
+		{"", "#4"},
+	} {
+		if reply := get(test.user); reply != test.reply {
+			t.Errorf("user %q: expect %v, got %v", test.user, test.reply, reply)
+		}
+	}
+	c := &cache{options: &options{ttl: time.Minute, key: func(context.Context) (string, bool) { return "", true }}}
+	if cc := c.cacheControl(&Entry{StoredAt: time.Now()}); !strings.HasPrefix(cc, "private, max-age=") {
+		t.Errorf("expect the scoped reply private, got %v", cc)
+	}
+}
+
+func TestLoadCanceled(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var (
+		calls  int32
+		loadTr transport.Transporter
+		err    error
+	)
+	h := Server()(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		loadTr, _ = transport.FromServerContext(ctx)
+		close(entered)
+		<-release
+		err = ctx.Err()
+		return wrapperspb.String("reply"), nil
+	})
+	tr := &Transport{header: headerCarrier{"X-User": "alice"}}
+	ctx, cancel := context.WithCancel(transport.NewServerContext(context.Background(), tr))
+	done := make(chan error)
+	go func() {
+		_, err := h(ctx, wrapperspb.String("a"))
+		done <- err
+	}()
+	<-entered
+	var reply interface{}
+	go func() {
+		var err error
+		reply, err = h(newContext(), wrapperspb.String("a"))
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if e := <-done; e != context.Canceled {
+		t.Errorf("expect %v, got %v", context.Canceled, e)
+	}
+	close(release)
+	if e := <-done; e != nil || reply.(*wrapperspb.StringValue).Value != "reply" {
+		t.Errorf("expect the waiter served, got %v %v", reply, e)
+	}
+	if err != nil || calls != 1 {
+		t.Errorf("expect the shared call not canceled, got %v in %v calls", err, calls)
+	}
+	if loadTr == transport.Transporter(tr) || loadTr.RequestHeader().Get("X-User") != "alice" {
+		t.Errorf("expect a copy of the transport, got %v", loadTr)
+	}
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Entry is the cached reply.
+type Entry struct {
+	// Reply is the cached reply.
+	Reply *anypb.Any
+	// ETag is the entity tag of the reply.
+	ETag string
+	// StoredAt is the time the reply is stored.
+	StoredAt time.Time
+}
+
+func (e *Entry) storedAt() time.Time {
+	if e == nil {
+		return time.Time{}
+	}
+	return e.StoredAt
+}
+
+// Store is the storage of the cached replies, e.g. Redis.
+type Store interface {
+	// Get returns the entry of the key, nil if absent.
+	Get(ctx context.Context, key string) (*Entry, error)
+	// Set stores the entry of the key for ttl.
+	Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error
+}
+
+// NewMemoryStore returns an in-memory store keeps at most size entries,
+// the least recently used entries are evicted.
+func NewMemoryStore(size int) Store {
+	return &memoryStore{
+		size:  size,
+		items: make(map[string]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+type memoryStore struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	lru   *list.List
+}
+
+type memoryItem struct {
+	key      string
+	entry    *Entry
+	expireAt time.Time
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+	item := e.Value.(*memoryItem)
+	if !time.Now().Before(item.expireAt) {
+		s.lru.Remove(e)
+		delete(s.items, key)
+		return nil, nil
+	}
+	s.lru.MoveToFront(e)
+	return item.entry, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, entry *Entry, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := &memoryItem{key: key, entry: entry, expireAt: time.Now().Add(ttl)}
+	if e, ok := s.items[key]; ok {
+		e.Value = item
+		s.lru.MoveToFront(e)
+		return nil
+	}
+	s.items[key] = s.lru.PushFront(item)
+	for s.size > 0 && s.lru.Len() > s.size {
+		e := s.lru.Back()
+		s.lru.Remove(e)
+		delete(s.items, e.Value.(*memoryItem).key)
+	}
+	return nil
+}
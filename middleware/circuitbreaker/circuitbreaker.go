@@ -2,6 +2,9 @@ package circuitbreaker
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-kratos/aegis/circuitbreaker"
 	"github.com/go-kratos/aegis/circuitbreaker/sre"
@@ -9,6 +12,7 @@ import (
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/internal/group"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/selector"
 	"github.com/go-kratos/kratos/v2/transport"
 )
 
@@ -35,40 +39,264 @@ func WithCircuitBreaker(genBreakerFunc func() circuitbreaker.CircuitBreaker) Opt
 	}
 }
 
+// WithKey with the func extracts the breaker key from the request,
+// default is the operation. The key is extracted before the node is selected,
+// see Node for the breakers of the selected nodes.
+func WithKey(fn KeyFunc) Option {
+	return func(o *options) {
+		o.key = fn
+	}
+}
+
+// WithFailureCodes with the error codes counted as failure,
+// default is 500, 503 and 504.
+func WithFailureCodes(codes ...int) Option {
+	return func(o *options) {
+		set := make(map[int]struct{}, len(codes))
+		for _, code := range codes {
+			set[code] = struct{}{}
+		}
+		o.isFailure = func(err error) bool {
+			_, ok := set[errors.Code(err)]
+			return ok
+		}
+	}
+}
+
+// WithFailureHandler with the func classifies whether an error is counted as failure.
+func WithFailureHandler(fn func(err error) bool) Option {
+	return func(o *options) {
+		o.isFailure = fn
+	}
+}
+
+// WithFallback with the func returns a degraded reply when the request is
+// rejected by the breaker or fails, the err is ErrNotAllowed or the failure.
+func WithFallback(fn FallbackFunc) Option {
+	return func(o *options) {
+		o.fallback = fn
+	}
+}
+
+// WithStateChange with the callback invoked when the breaker of a key
+// starts or stops rejecting requests. The state is observed from the sampled decisions
+// of the breaker, e.g. the SRE breaker rejects a part of the requests randomly, so the
+// key is open since a request is rejected and closed when no request is rejected for
+// the debounce duration, see WithStateDebounce.
+func WithStateChange(fn func(key string, from, to State)) Option {
+	return func(o *options) {
+		o.onStateChange = fn
+	}
+}
+
+// WithStateDebounce with the duration of no rejected request before the key is closed,
+// default is 3s, the window of the default SRE breaker.
+func WithStateDebounce(d time.Duration) Option {
+	return func(o *options) {
+		o.debounce = d
+	}
+}
+
+// KeyFunc extracts the breaker key from the request.
+type KeyFunc func(ctx context.Context, req interface{}) string
+
+// FallbackFunc returns a degraded reply for the failed request.
+type FallbackFunc func(ctx context.Context, req interface{}, err error) (interface{}, error)
+
+// State is the observed circuit breaker state.
+type State int
+
+const (
+	// StateClosed the breaker allows requests.
+	StateClosed State = iota
+	// StateOpen the breaker rejects requests.
+	StateOpen
+)
+
+// String returns the state name.
+func (s State) String() string {
+	if s == StateOpen {
+		return "open"
+	}
+	return "closed"
+}
+
+// OperationKey keys the breaker by the operation.
+func OperationKey(ctx context.Context, _ interface{}) string {
+	if info, ok := transport.FromClientContext(ctx); ok {
+		return info.Operation()
+	}
+	return ""
+}
+
+// HeaderKey keys the breaker by the operation and the request header values.
+func HeaderKey(keys ...string) KeyFunc {
+	return func(ctx context.Context, _ interface{}) string {
+		info, ok := transport.FromClientContext(ctx)
+		if !ok {
+			return ""
+		}
+		key := info.Operation()
+		for _, k := range keys {
+			key += "/" + info.RequestHeader().Get(k)
+		}
+		return key
+	}
+}
+
 type options struct {
-	group *group.Group
+	group         *group.Group
+	key           KeyFunc
+	isFailure     func(err error) bool
+	fallback      FallbackFunc
+	onStateChange func(key string, from, to State)
+	debounce      time.Duration
+	now           func() time.Time
 }
 
-// Client circuitbreaker middleware will return errBreakerTriggered when the circuit
-// breaker is triggered and the request is rejected directly.
-func Client(opts ...Option) middleware.Middleware {
-	opt := &options{
+func isFailure(err error) bool {
+	return errors.IsInternalServer(err) || errors.IsServiceUnavailable(err) || errors.IsGatewayTimeout(err)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
 		group: group.NewGroup(func() interface{} {
 			return sre.NewBreaker()
 		}),
+		key:       OperationKey,
+		isFailure: isFailure,
+		debounce:  3 * time.Second,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// stateTracker reports the state changes of the keys, it keeps the last rejected time of the open keys.
+type stateTracker struct {
+	*options
+	open sync.Map
+}
+
+func (t *stateTracker) set(key string, to State) {
+	if t.onStateChange == nil {
+		return
+	}
+	now := t.now().UnixNano()
+	if to == StateOpen {
+		if _, loaded := t.open.Swap(key, now); !loaded {
+			t.onStateChange(key, StateClosed, StateOpen)
+		}
+		return
+	}
+	last, ok := t.open.Load(key)
+	if !ok || time.Duration(now-last.(int64)) < t.debounce {
+		return
+	}
+	if t.open.CompareAndDelete(key, last) {
+		t.onStateChange(key, StateOpen, StateClosed)
+	}
+}
+
+// allow reports whether the breaker of the key allows the request.
+func (t *stateTracker) allow(key string) (circuitbreaker.CircuitBreaker, bool) {
+	breaker := t.group.Get(key).(circuitbreaker.CircuitBreaker)
+	if err := breaker.Allow(); err != nil {
+		// NOTE: when client reject requests locally,
+		// continue to add counter let the drop ratio higher.
+		breaker.MarkFailed()
+		t.set(key, StateOpen)
+		return breaker, false
+	}
+	t.set(key, StateClosed)
+	return breaker, true
+}
+
+// done marks the result of the allowed request on the breaker.
+func (t *stateTracker) done(ctx context.Context, req interface{}, breaker circuitbreaker.CircuitBreaker, reply interface{}, err error) (interface{}, error) {
+	if err != nil && t.isFailure(err) {
+		breaker.MarkFailed()
+		if t.fallback != nil {
+			return t.fallback(ctx, req, err)
+		}
+	} else {
+		breaker.MarkSuccess()
 	}
-	for _, o := range opts {
-		o(opt)
+	return reply, err
+}
+
+// rejected returns the reply of the request rejected by the breaker.
+func (t *stateTracker) rejected(ctx context.Context, req interface{}) (interface{}, error) {
+	if t.fallback != nil {
+		return t.fallback(ctx, req, ErrNotAllowed)
 	}
+	return nil, ErrNotAllowed
+}
+
+// Client circuitbreaker middleware will return errBreakerTriggered when the circuit
+// breaker is triggered and the request is rejected directly.
+func Client(opts ...Option) middleware.Middleware {
+	t := &stateTracker{options: newOptions(opts)}
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
-			info, _ := transport.FromClientContext(ctx)
-			breaker := opt.group.Get(info.Operation()).(circuitbreaker.CircuitBreaker)
-			if err := breaker.Allow(); err != nil {
-				// rejected
-				// NOTE: when client reject requests locally,
-				// continue to add counter let the drop ratio higher.
-				breaker.MarkFailed()
-				return nil, ErrNotAllowed
+			key := t.key(ctx, req)
+			breaker, ok := t.allow(key)
+			if !ok {
+				return t.rejected(ctx, req)
 			}
-			// allowed
 			reply, err := handler(ctx, req)
-			if err != nil && (errors.IsInternalServer(err) || errors.IsServiceUnavailable(err) || errors.IsGatewayTimeout(err)) {
-				breaker.MarkFailed()
-			} else {
-				breaker.MarkSuccess()
+			return t.done(ctx, req, breaker, reply, err)
+		}
+	}
+}
+
+type nodeStateKey struct{}
+
+// Node returns a node filter and a client middleware break the selected nodes per operation,
+// the breaker key is the address of the node and the operation, so WithKey is ignored.
+// The filter excludes the nodes whose breaker rejects the request, the request is rejected with
+// ErrNotAllowed if all the nodes are excluded, and the middleware marks the result on the breaker
+// of the node the selector chose. Both are required, e.g.
+// filter, m := circuitbreaker.Node()
+// conn, err := grpc.DialInsecure(ctx, grpc.WithNodeFilter(filter), grpc.WithMiddleware(m))
+func Node(opts ...Option) (selector.NodeFilter, middleware.Middleware) {
+	t := &stateTracker{options: newOptions(opts)}
+	filter := func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		info, ok := transport.FromClientContext(ctx)
+		if !ok || len(nodes) == 0 {
+			return nodes
+		}
+		allowed := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			if _, ok := t.allow(n.Address() + info.Operation()); ok {
+				allowed = append(allowed, n)
+			}
+		}
+		if rejected, ok := ctx.Value(nodeStateKey{}).(*atomic.Bool); ok && len(allowed) == 0 {
+			rejected.Store(true)
+		}
+		return allowed
+	}
+	m := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			rejected := new(atomic.Bool)
+			reply, err := handler(context.WithValue(ctx, nodeStateKey{}, rejected), req)
+			if err != nil && rejected.Load() {
+				return t.rejected(ctx, req)
+			}
+			info, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return reply, err
+			}
+			p, ok := selector.FromPeerContext(ctx)
+			if !ok || p.Node == nil {
+				return reply, err
 			}
-			return reply, err
+			breaker := t.group.Get(p.Node.Address() + info.Operation()).(circuitbreaker.CircuitBreaker)
+			return t.done(ctx, req, breaker, reply, err)
 		}
 	}
+	return filter, m
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	kratoserrors "github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/internal/group"
+	"github.com/go-kratos/kratos/v2/selector"
 	"github.com/go-kratos/kratos/v2/transport"
 )
 
@@ -77,3 +79,202 @@ func TestServer(_ *testing.T) {
 
 	_, _ = Client(func(_ *options) {})(nextInvalid)(ctx, nil)
 }
+
+type headerMock map[string]string
+
+func (h headerMock) Get(key string) string  { return h[key] }
+func (h headerMock) Set(key, value string)  { h[key] = value }
+func (h headerMock) Add(key, value string)  { h[key] = value }
+func (h headerMock) Keys() []string         { return nil }
+func (h headerMock) Values(string) []string { return nil }
+
+type headerTransportMock struct {
+	transportMock
+	header headerMock
+}
+
+func (tr *headerTransportMock) RequestHeader() transport.Header {
+	return tr.header
+}
+
+type switchBreakerMock struct {
+	err     error
+	success int
+	failed  int
+}
+
+func (c *switchBreakerMock) Allow() error { return c.err }
+func (c *switchBreakerMock) MarkSuccess() { c.success++ }
+func (c *switchBreakerMock) MarkFailed()  { c.failed++ }
+
+func TestKey(t *testing.T) {
+	ctx := transport.NewClientContext(context.Background(), &headerTransportMock{
+		transportMock: transportMock{endpoint: "127.0.0.1:9000", operation: "/helloworld.Greeter/SayHello"},
+		header:        headerMock{"x-tenant": "t1"},
+	})
+	if key := OperationKey(ctx, nil); key != "/helloworld.Greeter/SayHello" {
+		t.Errorf("expect %v, got %v", "/helloworld.Greeter/SayHello", key)
+	}
+	if key := HeaderKey("x-tenant")(ctx, nil); key != "/helloworld.Greeter/SayHello/t1" {
+		t.Errorf("expect %v, got %v", "/helloworld.Greeter/SayHello/t1", key)
+	}
+	if key := OperationKey(context.Background(), nil); key != "" {
+		t.Errorf("expect empty key, got %v", key)
+	}
+
+	var keys []string
+	m := Client(
+		WithKey(HeaderKey("x-tenant")),
+		WithGroup(group.NewGroup(func() interface{} { return &switchBreakerMock{} })),
+		WithStateChange(func(key string, _, _ State) { keys = append(keys, key) }),
+	)
+	_, _ = m(func(context.Context, interface{}) (interface{}, error) { return nil, nil })(ctx, nil)
+	if len(keys) != 0 {
+		t.Errorf("expect no state change, got %v", keys)
+	}
+}
+
+func TestFailureClassification(t *testing.T) {
+	b := &switchBreakerMock{}
+	m := Client(
+		WithGroup(group.NewGroup(func() interface{} { return b })),
+		WithFailureCodes(429),
+	)
+	ctx := transport.NewClientContext(context.Background(), &transportMock{})
+	_, _ = m(func(context.Context, interface{}) (interface{}, error) {
+		return nil, kratoserrors.InternalServer("", "")
+	})(ctx, nil)
+	_, _ = m(func(context.Context, interface{}) (interface{}, error) {
+		return nil, kratoserrors.New(429, "", "")
+	})(ctx, nil)
+	if b.success != 1 || b.failed != 1 {
+		t.Errorf("expect 1 success and 1 failure, got %v and %v", b.success, b.failed)
+	}
+
+	b = &switchBreakerMock{}
+	m = Client(
+		WithGroup(group.NewGroup(func() interface{} { return b })),
+		WithFailureHandler(func(err error) bool { return kratoserrors.IsBadRequest(err) }),
+	)
+	_, _ = m(func(context.Context, interface{}) (interface{}, error) {
+		return nil, kratoserrors.BadRequest("", "")
+	})(ctx, nil)
+	if b.failed != 1 {
+		t.Errorf("expect 1 failure, got %v", b.failed)
+	}
+}
+
+func TestFallbackAndStateChange(t *testing.T) {
+	b := &switchBreakerMock{err: errors.New("circuitbreaker error")}
+	type change struct{ from, to State }
+	var changes []change
+	m := Client(
+		WithGroup(group.NewGroup(func() interface{} { return b })),
+		WithFallback(func(_ context.Context, _ interface{}, err error) (interface{}, error) {
+			if kratoserrors.Is(err, ErrNotAllowed) {
+				return "degraded", nil
+			}
+			return "failed", nil
+		}),
+		WithStateChange(func(_ string, from, to State) { changes = append(changes, change{from, to}) }),
+		WithStateDebounce(0),
+	)
+	ctx := transport.NewClientContext(context.Background(), &transportMock{})
+	next := func(context.Context, interface{}) (interface{}, error) {
+		return nil, kratoserrors.ServiceUnavailable("", "")
+	}
+	reply, err := m(next)(ctx, nil)
+	if err != nil || reply != "degraded" {
+		t.Errorf("expect %v, got %v, %v", "degraded", reply, err)
+	}
+	_, _ = m(next)(ctx, nil)
+	b.err = nil
+	reply, err = m(next)(ctx, nil)
+	if err != nil || reply != "failed" {
+		t.Errorf("expect %v, got %v, %v", "failed", reply, err)
+	}
+	if len(changes) != 2 || changes[0] != (change{StateClosed, StateOpen}) || changes[1] != (change{StateOpen, StateClosed}) {
+		t.Errorf("unexpected state changes: %v", changes)
+	}
+	if StateOpen.String() != "open" || StateClosed.String() != "closed" {
+		t.Errorf("unexpected state names: %v, %v", StateOpen, StateClosed)
+	}
+}
+
+func withNow(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+func TestStateDebounce(t *testing.T) {
+	b := &switchBreakerMock{}
+	var changes []State
+	now := time.Unix(0, 0)
+	m := Client(
+		WithGroup(group.NewGroup(func() interface{} { return b })),
+		WithStateChange(func(_ string, _, to State) { changes = append(changes, to) }),
+		WithStateDebounce(time.Second),
+		withNow(func() time.Time { return now }),
+	)
+	ctx := transport.NewClientContext(context.Background(), &transportMock{})
+	h := m(func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+
+	// the sampled rejections and allowances don't flap the state
+	for i := 0; i < 10; i++ {
+		b.err = nil
+		if i%2 == 0 {
+			b.err = errors.New("circuitbreaker error")
+		}
+		_, _ = h(ctx, nil)
+		now = now.Add(100 * time.Millisecond)
+	}
+	if len(changes) != 1 || changes[0] != StateOpen {
+		t.Fatalf("expect [open], got %v", changes)
+	}
+	b.err = nil
+	now = now.Add(500 * time.Millisecond)
+	_, _ = h(ctx, nil)
+	if len(changes) != 1 {
+		t.Fatalf("expect [open], got %v", changes)
+	}
+	now = now.Add(500 * time.Millisecond)
+	_, _ = h(ctx, nil)
+	if len(changes) != 2 || changes[1] != StateClosed {
+		t.Fatalf("expect [open closed], got %v", changes)
+	}
+}
+
+func TestNode(t *testing.T) {
+	g := group.NewGroup(func() interface{} { return &switchBreakerMock{} })
+	open := g.Get("10.0.0.1:9000/helloworld.Greeter/SayHello").(*switchBreakerMock)
+	open.err = errors.New("circuitbreaker error")
+	filter, m := Node(WithGroup(g))
+	nodes := []selector.Node{
+		selector.NewNode("grpc", "10.0.0.1:9000", nil),
+		selector.NewNode("grpc", "10.0.0.2:9000", nil),
+	}
+	// the handler selects the first node the filter keeps like the selector
+	h := m(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		candidates := filter(ctx, nodes)
+		if len(candidates) == 0 {
+			return nil, selector.ErrNoAvailable
+		}
+		p, _ := selector.FromPeerContext(ctx)
+		p.Node = candidates[0]
+		return nil, kratoserrors.ServiceUnavailable("", "")
+	})
+	ctx := transport.NewClientContext(context.Background(), &transportMock{operation: "/helloworld.Greeter/SayHello"})
+	if _, err := h(selector.NewPeerContext(ctx, &selector.Peer{}), nil); !kratoserrors.IsServiceUnavailable(err) {
+		t.Errorf("expect service unavailable, got %v", err)
+	}
+	selected := g.Get("10.0.0.2:9000/helloworld.Greeter/SayHello").(*switchBreakerMock)
+	if selected.failed != 1 || open.failed != 1 || open.success != 0 {
+		t.Errorf("expect the failure marked on the selected node, got %+v and %+v", selected, open)
+	}
+
+	selected.err = open.err
+	if _, err := h(selector.NewPeerContext(ctx, &selector.Peer{}), nil); !kratoserrors.Is(err, ErrNotAllowed) {
+		t.Errorf("expect %v, got %v", ErrNotAllowed, err)
+	}
+}
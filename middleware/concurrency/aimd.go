@@ -0,0 +1,64 @@
+package concurrency
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+var _ ratelimit.Limiter = (*AIMD)(nil)
+
+// AIMD is an additive-increase/multiplicative-decrease concurrency limiter.
+// The limit grows by one while the in-flight requests use at least half of it,
+// and is multiplied by the backoff ratio when the downstream is overloaded.
+type AIMD struct {
+	mu       sync.Mutex
+	opts     limiterOptions
+	limit    float64
+	inflight int64
+}
+
+// NewAIMD new an AIMD limiter.
+func NewAIMD(opts ...LimiterOption) *AIMD {
+	o := defaultLimiterOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &AIMD{
+		opts:  o,
+		limit: clamp(o.initialLimit, o.minLimit, o.maxLimit),
+	}
+}
+
+// Allow checks whether a new request is allowed.
+func (l *AIMD) Allow() (ratelimit.DoneFunc, error) {
+	l.mu.Lock()
+	if float64(l.inflight) >= l.limit {
+		l.mu.Unlock()
+		return nil, ratelimit.ErrLimitExceed
+	}
+	l.inflight++
+	inflight := l.inflight
+	l.mu.Unlock()
+	start := time.Now()
+	return func(di ratelimit.DoneInfo) {
+		overload := l.opts.overload(di.Err) || (l.opts.latency > 0 && time.Since(start) > l.opts.latency)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.inflight--
+		switch {
+		case overload:
+			l.limit = clamp(l.limit*l.opts.backoff, l.opts.minLimit, l.opts.maxLimit)
+		case float64(inflight*2) >= l.limit:
+			l.limit = clamp(l.limit+1, l.opts.minLimit, l.opts.maxLimit)
+		}
+	}, nil
+}
+
+// Limit returns the current concurrency limit.
+func (l *AIMD) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
@@ -0,0 +1,83 @@
+package concurrency
+
+import (
+	"context"
+	"net"
+
+	"github.com/go-kratos/aegis/ratelimit"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/group"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// ErrLimitExceed is request rejected locally due to concurrency limit exceeded.
+var ErrLimitExceed = errors.New(429, "CONCURRENCY_LIMIT", "request rejected due to concurrency limit exceeded")
+
+// Option is concurrency limit option.
+type Option func(*options)
+
+// WithGroup with limiter group.
+// NOTE: implements generics ratelimit.Limiter
+func WithGroup(g *group.Group) Option {
+	return func(o *options) {
+		o.group = g
+	}
+}
+
+// WithLimiter with limiter genFunc.
+func WithLimiter(genLimiterFunc func() ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.group = group.NewGroup(func() interface{} {
+			return genLimiterFunc()
+		})
+	}
+}
+
+type options struct {
+	group *group.Group
+}
+
+// Client concurrency limit middleware will return ErrLimitExceed when the
+// in-flight requests to the target service/operation exceed the adaptive limit.
+func Client(opts ...Option) middleware.Middleware {
+	opt := &options{
+		group: group.NewGroup(func() interface{} {
+			return NewAIMD()
+		}),
+	}
+	for _, o := range opts {
+		o(opt)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var key string
+			if info, ok := transport.FromClientContext(ctx); ok {
+				key = info.Endpoint() + info.Operation()
+			}
+			limiter := opt.group.Get(key).(ratelimit.Limiter)
+			done, err := limiter.Allow()
+			if err != nil {
+				// rejected
+				return nil, ErrLimitExceed
+			}
+			// allowed
+			reply, err := handler(ctx, req)
+			done(ratelimit.DoneInfo{Err: err})
+			return reply, err
+		}
+	}
+}
+
+// isOverload reports whether the error indicates the downstream is overloaded.
+func isOverload(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return true
+	}
+	return errors.Code(err) == 429 || errors.IsServiceUnavailable(err) || errors.IsGatewayTimeout(err)
+}
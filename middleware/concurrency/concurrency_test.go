@@ -0,0 +1,204 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/group"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type transportMock struct {
+	endpoint  string
+	operation string
+}
+
+func (tr *transportMock) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *transportMock) Endpoint() string                { return tr.endpoint }
+func (tr *transportMock) Operation() string               { return tr.operation }
+func (tr *transportMock) RequestHeader() transport.Header { return nil }
+func (tr *transportMock) ReplyHeader() transport.Header   { return nil }
+
+func Test_WithGroup(t *testing.T) {
+	const n = 3
+	m := Client(WithGroup(group.NewGroup(func() interface{} {
+		return NewAIMD(WithInitialLimit(n), WithMaxLimit(n))
+	})))
+	ctx := transport.NewClientContext(context.Background(), &transportMock{
+		endpoint:  "discovery:///helloworld",
+		operation: "/helloworld.Greeter/SayHello",
+	})
+	entered := make(chan struct{})
+	releases := make([]chan struct{}, n)
+	dones := make([]chan error, n)
+	for i := 0; i < n; i++ {
+		releases[i], dones[i] = make(chan struct{}), make(chan error, 1)
+		go func(i int) {
+			_, err := m(func(context.Context, interface{}) (interface{}, error) {
+				entered <- struct{}{}
+				<-releases[i]
+				return "reply", nil
+			})(ctx, nil)
+			dones[i] <- err
+		}(i)
+		<-entered
+	}
+	next := func(context.Context, interface{}) (interface{}, error) { return "reply", nil }
+	_, err := m(next)(ctx, nil)
+	if se := errors.FromError(err); se.Code != 429 || se.Reason != "CONCURRENCY_LIMIT" {
+		t.Fatalf("expect %v, got %v", ErrLimitExceed, err)
+	}
+	// the slot is released after the call completes
+	close(releases[0])
+	if err = <-dones[0]; err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := m(next)(ctx, nil); err != nil || reply != "reply" {
+		t.Errorf("expect %v, got %v, %v", "reply", reply, err)
+	}
+	for i := 1; i < n; i++ {
+		close(releases[i])
+		if err = <-dones[i]; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	var limiters []*AIMD
+	m := Client(WithLimiter(func() ratelimit.Limiter {
+		l := NewAIMD(WithInitialLimit(1))
+		limiters = append(limiters, l)
+		return l
+	}))
+	block := make(chan struct{})
+	started := make(chan struct{})
+	ctx := transport.NewClientContext(context.Background(), &transportMock{
+		endpoint:  "discovery:///helloworld",
+		operation: "/helloworld.Greeter/SayHello",
+	})
+	go func() {
+		_, _ = m(func(context.Context, interface{}) (interface{}, error) {
+			close(started)
+			<-block
+			return "reply", nil
+		})(ctx, nil)
+	}()
+	<-started
+	_, err := m(func(context.Context, interface{}) (interface{}, error) {
+		return "reply", nil
+	})(ctx, nil)
+	if !errors.Is(err, ErrLimitExceed) {
+		t.Errorf("expect %v, got %v", ErrLimitExceed, err)
+	}
+	close(block)
+
+	other := transport.NewClientContext(context.Background(), &transportMock{
+		endpoint:  "discovery:///helloworld",
+		operation: "/helloworld.Greeter/SayBye",
+	})
+	reply, err := m(func(context.Context, interface{}) (interface{}, error) {
+		return "reply", nil
+	})(other, nil)
+	if err != nil || reply != "reply" {
+		t.Errorf("expect %v, got %v, %v", "reply", reply, err)
+	}
+	if len(limiters) != 2 {
+		t.Errorf("expect %v limiters, got %v", 2, len(limiters))
+	}
+}
+
+func TestAIMD(t *testing.T) {
+	l := NewAIMD(WithInitialLimit(10), WithMinLimit(2), WithMaxLimit(12), WithBackoffRatio(0.5))
+	dones := make([]ratelimit.DoneFunc, 0, 10)
+	for i := 0; i < 10; i++ {
+		done, err := l.Allow()
+		if err != nil {
+			t.Fatalf("expect %v, got %v", nil, err)
+		}
+		dones = append(dones, done)
+	}
+	if _, err := l.Allow(); err == nil {
+		t.Fatal("expect limit exceeded")
+	}
+	for _, done := range dones {
+		done(ratelimit.DoneInfo{})
+	}
+	if l.Limit() != 12 {
+		t.Errorf("expect %v, got %v", 12, l.Limit())
+	}
+	for i := 0; i < 5; i++ {
+		done, _ := l.Allow()
+		done(ratelimit.DoneInfo{Err: errors.ServiceUnavailable("", "")})
+	}
+	if l.Limit() != 2 {
+		t.Errorf("expect %v, got %v", 2, l.Limit())
+	}
+	// business errors are not overload
+	done, _ := l.Allow()
+	done(ratelimit.DoneInfo{Err: errors.BadRequest("", "")})
+	if l.Limit() != 3 {
+		t.Errorf("expect %v, got %v", 3, l.Limit())
+	}
+}
+
+func TestAIMDLatency(t *testing.T) {
+	l := NewAIMD(WithInitialLimit(10), WithLatencyThreshold(time.Millisecond))
+	done, _ := l.Allow()
+	time.Sleep(5 * time.Millisecond)
+	done(ratelimit.DoneInfo{})
+	if l.Limit() != 9 {
+		t.Errorf("expect %v, got %v", 9, l.Limit())
+	}
+}
+
+func TestGradient(t *testing.T) {
+	l := NewGradient(WithInitialLimit(4), WithMaxLimit(100), WithSmoothing(1), WithWindow(1000))
+	for i := 0; i < 20; i++ {
+		dones := make([]ratelimit.DoneFunc, 0, l.Limit())
+		for j := l.Limit(); j > 0; j-- {
+			done, err := l.Allow()
+			if err != nil {
+				t.Fatalf("expect %v, got %v", nil, err)
+			}
+			dones = append(dones, done)
+		}
+		time.Sleep(time.Millisecond)
+		for _, done := range dones {
+			done(ratelimit.DoneInfo{})
+		}
+	}
+	grown := l.Limit()
+	if grown <= 4 {
+		t.Fatalf("expect limit to grow, got %v", grown)
+	}
+	done, _ := l.Allow()
+	done(ratelimit.DoneInfo{Err: context.DeadlineExceeded})
+	if l.Limit() >= grown {
+		t.Errorf("expect limit below %v, got %v", grown, l.Limit())
+	}
+}
+
+func TestIsOverload(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.BadRequest("", ""), false},
+		{errors.InternalServer("", ""), false},
+		{errors.ServiceUnavailable("", ""), true},
+		{errors.GatewayTimeout("", ""), true},
+		{errors.New(429, "", ""), true},
+		{context.DeadlineExceeded, true},
+	}
+	for _, test := range tests {
+		if got := isOverload(test.err); got != test.want {
+			t.Errorf("%v: expect %v, got %v", test.err, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package concurrency
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+var _ ratelimit.Limiter = (*Gradient)(nil)
+
+// Gradient is a latency gradient concurrency limiter.
+// It compares the latency of each request with the long-term average,
+// shrinks the limit as the latency grows and leaves sqrt(limit) headroom for queueing.
+type Gradient struct {
+	mu       sync.Mutex
+	opts     limiterOptions
+	limit    float64
+	inflight int64
+	longRTT  float64
+}
+
+// NewGradient new a gradient limiter.
+func NewGradient(opts ...LimiterOption) *Gradient {
+	o := defaultLimiterOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Gradient{
+		opts:  o,
+		limit: clamp(o.initialLimit, o.minLimit, o.maxLimit),
+	}
+}
+
+// Allow checks whether a new request is allowed.
+func (l *Gradient) Allow() (ratelimit.DoneFunc, error) {
+	l.mu.Lock()
+	if float64(l.inflight) >= l.limit {
+		l.mu.Unlock()
+		return nil, ratelimit.ErrLimitExceed
+	}
+	l.inflight++
+	inflight := l.inflight
+	l.mu.Unlock()
+	start := time.Now()
+	return func(di ratelimit.DoneInfo) {
+		rtt := float64(time.Since(start))
+		overload := l.opts.overload(di.Err)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.inflight--
+		if overload {
+			l.limit = clamp(l.limit*l.opts.backoff, l.opts.minLimit, l.opts.maxLimit)
+			return
+		}
+		if l.longRTT == 0 {
+			l.longRTT = rtt
+		} else {
+			alpha := 2 / (float64(l.opts.window) + 1)
+			l.longRTT = l.longRTT*(1-alpha) + rtt*alpha
+		}
+		// the limit is not the bottleneck, don't grow it
+		if float64(inflight*2) < l.limit || rtt == 0 {
+			return
+		}
+		gradient := clamp(l.opts.tolerance*l.longRTT/rtt, 0.5, 1)
+		newLimit := l.limit*gradient + math.Sqrt(l.limit)
+		newLimit = l.limit*(1-l.opts.smoothing) + newLimit*l.opts.smoothing
+		l.limit = clamp(newLimit, l.opts.minLimit, l.opts.maxLimit)
+	}, nil
+}
+
+// Limit returns the current concurrency limit.
+func (l *Gradient) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
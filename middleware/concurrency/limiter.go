@@ -0,0 +1,106 @@
+package concurrency
+
+import (
+	"time"
+)
+
+// LimiterOption is adaptive limiter option.
+type LimiterOption func(*limiterOptions)
+
+type limiterOptions struct {
+	initialLimit float64
+	minLimit     float64
+	maxLimit     float64
+	backoff      float64
+	latency      time.Duration
+	tolerance    float64
+	smoothing    float64
+	window       int
+	overload     func(err error) bool
+}
+
+func defaultLimiterOptions() limiterOptions {
+	return limiterOptions{
+		initialLimit: 20,
+		minLimit:     1,
+		maxLimit:     1000,
+		backoff:      0.9,
+		tolerance:    2,
+		smoothing:    0.2,
+		window:       600,
+		overload:     isOverload,
+	}
+}
+
+// WithInitialLimit with the initial concurrency limit.
+func WithInitialLimit(limit int) LimiterOption {
+	return func(o *limiterOptions) {
+		o.initialLimit = float64(limit)
+	}
+}
+
+// WithMinLimit with the minimum concurrency limit.
+func WithMinLimit(limit int) LimiterOption {
+	return func(o *limiterOptions) {
+		o.minLimit = float64(limit)
+	}
+}
+
+// WithMaxLimit with the maximum concurrency limit.
+func WithMaxLimit(limit int) LimiterOption {
+	return func(o *limiterOptions) {
+		o.maxLimit = float64(limit)
+	}
+}
+
+// WithBackoffRatio with the ratio the limit is multiplied by on overload.
+func WithBackoffRatio(ratio float64) LimiterOption {
+	return func(o *limiterOptions) {
+		o.backoff = ratio
+	}
+}
+
+// WithLatencyThreshold with the latency above which a request counts as overload (AIMD only).
+func WithLatencyThreshold(d time.Duration) LimiterOption {
+	return func(o *limiterOptions) {
+		o.latency = d
+	}
+}
+
+// WithTolerance with the tolerated ratio of the current latency to the long-term latency (Gradient only).
+func WithTolerance(tolerance float64) LimiterOption {
+	return func(o *limiterOptions) {
+		o.tolerance = tolerance
+	}
+}
+
+// WithSmoothing with the smoothing factor of limit updates (Gradient only).
+func WithSmoothing(smoothing float64) LimiterOption {
+	return func(o *limiterOptions) {
+		o.smoothing = smoothing
+	}
+}
+
+// WithWindow with the number of samples of the long-term latency average (Gradient only).
+func WithWindow(window int) LimiterOption {
+	return func(o *limiterOptions) {
+		o.window = window
+	}
+}
+
+// WithOverloadHandler with the func classifies whether an error means the downstream is overloaded.
+func WithOverloadHandler(fn func(err error) bool) LimiterOption {
+	return func(o *limiterOptions) {
+		o.overload = fn
+	}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
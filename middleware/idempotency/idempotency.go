@@ -0,0 +1,247 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	ic "github.com/go-kratos/kratos/v2/internal/context"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const (
+	// DefaultHeader is the default header of the idempotency key.
+	DefaultHeader = "Idempotency-Key"
+	// ReplayedHeader is the reply header set to "true" if the reply is replayed.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	reason = "IDEMPOTENCY"
+)
+
+var (
+	ErrMissingKey   = errors.BadRequest(reason, "idempotency key is missing")
+	ErrConflict     = errors.Conflict(reason, "request with the same idempotency key is in progress")
+	ErrKeyReused    = errors.New(422, reason, "idempotency key is reused with a different request")
+	ErrWrongContext = errors.Unauthorized(reason, "wrong context for middleware")
+)
+
+// KeyFunc returns the scope of the idempotency keys, e.g. the user ID,
+// ok is false if the caller can't be identified.
+type KeyFunc func(ctx context.Context) (key string, ok bool)
+
+// Option is idempotency option.
+type Option func(*options)
+
+type options struct {
+	header   string
+	scope    KeyFunc
+	store    Store
+	ttl      time.Duration
+	lockTTL  time.Duration
+	timeout  time.Duration
+	required bool
+}
+
+// WithHeader with the header of the idempotency key, default is Idempotency-Key.
+func WithHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithScope with the scope of the idempotency keys, so a caller can't replay the results of
+// the others by reusing their keys. The requests of the unidentified callers are handled as
+// the requests without the idempotency key.
+// e.g. WithScope(func(ctx context.Context) (string, bool) { claims, ok := jwt.FromContext(ctx) ... })
+func WithScope(scope KeyFunc) Option {
+	return func(o *options) {
+		o.scope = scope
+	}
+}
+
+// WithStore with the store of the results, default is an in-memory LRU store of 10000 keys.
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithTTL with the retention of the completed results, default is 24h.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithLockTTL with the retention of the in-progress markers, default is 1m,
+// it should exceed the timeout of the handler.
+func WithLockTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.lockTTL = ttl
+	}
+}
+
+// WithStoreTimeout with the timeout of saving the result and releasing the key, default is 5s.
+// They run on a context detached from the request, so they complete when the request is canceled.
+func WithStoreTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithRequired with the requests without the idempotency key rejected.
+func WithRequired() Option {
+	return func(o *options) {
+		o.required = true
+	}
+}
+
+// Server is a server middleware makes the requests idempotent by the idempotency key.
+// Only the proto message replies and the errors with the 4xx codes are kept as the results,
+// while the other replies and errors release the key for the retries.
+// e.g. selector.Server(idempotency.Server()).Path("/payment.v1.Payment/Charge").Build()
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{
+		header:  DefaultHeader,
+		ttl:     24 * time.Hour,
+		lockTTL: time.Minute,
+		timeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.store == nil {
+		o.store = NewMemoryStore(10000) //nolint:gomnd
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			if middleware.IsStream(ctx) {
+				// the streams have no request to replay
+				return handler(ctx, req)
+			}
+			key := tr.RequestHeader().Get(o.header)
+			var scope string
+			if key != "" && o.scope != nil {
+				if scope, ok = o.scope(ctx); !ok {
+					key = ""
+				}
+			}
+			if key == "" {
+				if o.required {
+					return nil, ErrMissingKey
+				}
+				return handler(ctx, req)
+			}
+			key = tr.Operation() + ":" + strconv.Quote(scope) + ":" + key
+			fp, err := fingerprint(tr.Operation(), req)
+			if err != nil {
+				return nil, err
+			}
+			existing, err := o.store.Reserve(ctx, key, &Record{Fingerprint: fp}, o.lockTTL)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				return replay(tr, existing, fp)
+			}
+			saved := false
+			defer func() {
+				// release the key if the result isn't kept, including the panics of the handler
+				if saved {
+					return
+				}
+				dctx, cancel := o.detach(ctx)
+				defer cancel()
+				if derr := o.store.Delete(dctx, key); derr != nil {
+					log.Context(ctx).Errorf("idempotency: failed to release the key %s: %v", key, derr)
+				}
+			}()
+			reply, err := handler(ctx, req)
+			if record, ok := complete(fp, reply, err); ok {
+				sctx, cancel := o.detach(ctx)
+				if serr := o.store.Save(sctx, key, record, o.ttl); serr != nil {
+					log.Context(ctx).Errorf("idempotency: failed to save the result of the key %s: %v", key, serr)
+				} else {
+					saved = true
+				}
+				cancel()
+			}
+			return reply, err
+		}
+	}
+}
+
+// detach returns the context of the store calls completing the request,
+// it keeps the values of the request but isn't canceled with it.
+func (o *options) detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ic.Detach(ctx), o.timeout)
+}
+
+// replay returns the result of the existing record.
+func replay(tr transport.Transporter, record *Record, fingerprint string) (interface{}, error) {
+	if record.Fingerprint != fingerprint {
+		return nil, ErrKeyReused
+	}
+	if !record.Done {
+		return nil, ErrConflict
+	}
+	tr.ReplyHeader().Set(ReplayedHeader, "true")
+	if record.Error != nil {
+		return nil, errors.Clone(record.Error)
+	}
+	return record.Reply.UnmarshalNew()
+}
+
+// complete returns the completed record of the result, ok is false if the result is not kept.
+func complete(fingerprint string, reply interface{}, err error) (record *Record, ok bool) {
+	if err != nil {
+		se := errors.FromError(err)
+		if se.Code < 400 || se.Code >= 500 {
+			return nil, false
+		}
+		return &Record{Fingerprint: fingerprint, Done: true, Error: se}, true
+	}
+	m, ok := reply.(proto.Message)
+	if !ok {
+		return nil, false
+	}
+	replyAny, err := anypb.New(m)
+	if err != nil {
+		return nil, false
+	}
+	return &Record{Fingerprint: fingerprint, Done: true, Reply: replyAny}, true
+}
+
+// fingerprint returns the digest of the operation and the deterministic encoding of the request.
+func fingerprint(operation string, req interface{}) (string, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if m, ok := req.(proto.Message); ok {
+		b, err = proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	} else {
+		b, err = json.Marshal(req)
+	}
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(operation))
+	h.Write([]byte{0})
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,304 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	pb "github.com/go-kratos/kratos/v2/internal/testdata/helloworld"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string  { return hc[key] }
+func (hc headerCarrier) Set(key, value string)  { hc[key] = value }
+func (hc headerCarrier) Add(key, value string)  { hc[key] = value }
+func (hc headerCarrier) Keys() []string         { return nil }
+func (hc headerCarrier) Values(string) []string { return nil }
+
+type Transport struct {
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+func (tr *Transport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *Transport) Endpoint() string                { return "" }
+func (tr *Transport) Operation() string               { return "/payment.v1.Payment/Charge" }
+func (tr *Transport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *Transport) ReplyHeader() transport.Header   { return tr.replyHeader }
+
+func newContext(key string) (context.Context, *Transport) {
+	tr := &Transport{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+	if key != "" {
+		tr.reqHeader[DefaultHeader] = key
+	}
+	return transport.NewServerContext(context.Background(), tr), tr
+}
+
+func TestServer(t *testing.T) {
+	var calls int
+	h := Server()(func(_ context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return wrapperspb.String("charged " + req.(*wrapperspb.StringValue).Value), nil
+	})
+
+	req := wrapperspb.String("100")
+	ctx, _ := newContext("k1")
+	first, err := h(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, tr := newContext("k1")
+	replayed, err := h(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || !proto.Equal(first.(proto.Message), replayed.(proto.Message)) || tr.replyHeader[ReplayedHeader] != "true" {
+		t.Errorf("expect the reply replayed, got %v after %v calls", replayed, calls)
+	}
+	ctx, _ = newContext("k1")
+	if _, err = h(ctx, wrapperspb.String("200")); !errors.Is(err, ErrKeyReused) {
+		t.Errorf("expect %v, got %v", ErrKeyReused, err)
+	}
+	ctx, _ = newContext("")
+	if _, err = h(ctx, req); err != nil || calls != 2 {
+		t.Errorf("expect the request without key passed, got %v after %v calls", err, calls)
+	}
+	ctx, _ = newContext("")
+	if _, err = Server(WithRequired())(h)(ctx, req); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("expect %v, got %v", ErrMissingKey, err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	var calls int
+	h := Server(WithRequired())(func(context.Context, interface{}) (interface{}, error) {
+		calls++
+		return nil, nil
+	})
+	for i := 0; i < 2; i++ {
+		ctx, _ := newContext("")
+		if _, err := h(middleware.NewStreamContext(ctx, middleware.StreamCallWhole), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expect the streams passed, got %v calls", calls)
+	}
+}
+
+func TestConflict(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	h := Server()(func(context.Context, interface{}) (interface{}, error) {
+		close(started)
+		<-release
+		return wrapperspb.String("ok"), nil
+	})
+	done := make(chan error)
+	go func() {
+		ctx, _ := newContext("k1")
+		_, err := h(ctx, wrapperspb.String("req"))
+		done <- err
+	}()
+	<-started
+	ctx, _ := newContext("k1")
+	if _, err := h(ctx, wrapperspb.String("req")); !errors.Is(err, ErrConflict) {
+		t.Errorf("expect %v, got %v", ErrConflict, err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var calls int
+	errs := []error{kerrors.ServiceUnavailable("UNAVAILABLE", ""), kerrors.BadRequest("INVALID", "")}
+	h := Server()(func(context.Context, interface{}) (interface{}, error) {
+		err := errs[calls]
+		calls++
+		return nil, err
+	})
+	for _, expected := range []error{errs[0], errs[1], errs[1]} {
+		ctx, _ := newContext("k1")
+		if _, err := h(ctx, wrapperspb.String("req")); !errors.Is(err, expected) {
+			t.Errorf("expect %v, got %v", expected, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expect the 5xx error retried and the 4xx error kept, got %v calls", calls)
+	}
+}
+
+func TestScope(t *testing.T) {
+	var calls int
+	h := Server(WithScope(func(ctx context.Context) (string, bool) {
+		tr, _ := transport.FromServerContext(ctx)
+		user := tr.RequestHeader().Get("X-User")
+		return user, user != ""
+	}))(func(context.Context, interface{}) (interface{}, error) {
+		calls++
+		return wrapperspb.String("ok"), nil
+	})
+	for _, user := range []string{"alice", "bob", "alice", "", ""} {
+		ctx, tr := newContext("k1")
+		if user != "" {
+			tr.reqHeader["X-User"] = user
+		}
+		if _, err := h(ctx, wrapperspb.String("req")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 4 {
+		t.Errorf("expect the keys scoped per caller, got %v calls", calls)
+	}
+}
+
+// ctxStore fails the calls on the canceled contexts.
+type ctxStore struct {
+	Store
+	calls []string
+}
+
+func (s *ctxStore) Save(ctx context.Context, key string, record *Record, ttl time.Duration) error {
+	s.calls = append(s.calls, "save")
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Store.Save(ctx, key, record, ttl)
+}
+
+func (s *ctxStore) Delete(ctx context.Context, key string) error {
+	s.calls = append(s.calls, "delete")
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Store.Delete(ctx, key)
+}
+
+func TestCanceled(t *testing.T) {
+	store := &ctxStore{Store: NewMemoryStore(10)}
+	var calls int
+	h := Server(WithStore(store))(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		calls++
+		// the request is canceled before the handler returns
+		ctx.Value(cancelKey{}).(context.CancelFunc)()
+		if calls == 1 {
+			return nil, kerrors.ServiceUnavailable("UNAVAILABLE", "")
+		}
+		return wrapperspb.String("ok"), nil
+	})
+	call := func() (interface{}, error) {
+		ctx, _ := newContext("k1")
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		return h(context.WithValue(ctx, cancelKey{}, cancel), wrapperspb.String("req"))
+	}
+	// the key is released though the request is canceled
+	if _, err := call(); !kerrors.IsServiceUnavailable(err) {
+		t.Fatalf("expect service unavailable, got %v", err)
+	}
+	first, err := call()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the result is saved though the request is canceled
+	replayed, err := call()
+	if err != nil || calls != 2 || !proto.Equal(first.(proto.Message), replayed.(proto.Message)) {
+		t.Errorf("expect the reply replayed, got %v, %v after %v calls", replayed, err, calls)
+	}
+	if len(store.calls) != 2 || store.calls[0] != "delete" || store.calls[1] != "save" {
+		t.Errorf("unexpected store calls: %v", store.calls)
+	}
+}
+
+type cancelKey struct{}
+
+func TestPanic(t *testing.T) {
+	var calls int
+	h := Server()(func(context.Context, interface{}) (interface{}, error) {
+		if calls++; calls == 1 {
+			panic("boom")
+		}
+		return wrapperspb.String("ok"), nil
+	})
+	func() {
+		defer func() { _ = recover() }()
+		ctx, _ := newContext("k1")
+		_, _ = h(ctx, wrapperspb.String("req"))
+	}()
+	ctx, _ := newContext("k1")
+	if _, err := h(ctx, wrapperspb.String("req")); err != nil || calls != 2 {
+		t.Errorf("expect the key released after the panic, got %v after %v calls", err, calls)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(2)
+	for _, key := range []string{"a", "b", "c"} {
+		if existing, _ := s.Reserve(ctx, key, &Record{Fingerprint: key}, time.Minute); existing != nil {
+			t.Errorf("expect %s reserved", key)
+		}
+	}
+	if existing, _ := s.Reserve(ctx, "a", &Record{}, time.Minute); existing != nil {
+		t.Error("expect the least recently used key evicted")
+	}
+	if existing, _ := s.Reserve(ctx, "c", &Record{}, time.Minute); existing == nil || existing.Fingerprint != "c" {
+		t.Errorf("expect the existing record, got %v", existing)
+	}
+	_ = s.Save(ctx, "d", &Record{}, -time.Second)
+	if existing, _ := s.Reserve(ctx, "d", &Record{}, time.Minute); existing != nil {
+		t.Error("expect the expired record replaced")
+	}
+}
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+	calls int
+}
+
+func (g *greeter) SayHello(_ context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	g.calls++
+	return &pb.HelloReply{Message: fmt.Sprintf("hello %s #%d", in.Name, g.calls)}, nil
+}
+
+func TestHTTP(t *testing.T) {
+	g := &greeter{}
+	srv := khttp.NewServer(khttp.Middleware(Server()))
+	pb.RegisterGreeterHTTPServer(srv, g)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	get := func(key string) (string, string) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/helloworld/kratos", nil)
+		req.Header.Set(DefaultHeader, key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b), resp.Header.Get(ReplayedHeader)
+	}
+	first, _ := get("k1")
+	replayed, header := get("k1")
+	if first != replayed || header != "true" || g.calls != 1 {
+		t.Errorf("expect the reply replayed, got %s %s after %v calls", replayed, header, g.calls)
+	}
+	if other, _ := get("k2"); other == first {
+		t.Errorf("expect a new reply of the other key, got %s", other)
+	}
+}
@@ -0,0 +1,104 @@
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// Record is the in-progress marker or the result of an idempotency key.
+type Record struct {
+	// Fingerprint is the digest of the operation and the request.
+	Fingerprint string
+	// Done reports whether the request is completed.
+	Done bool
+	// Reply is the reply of the completed request.
+	Reply *anypb.Any
+	// Error is the error of the completed request.
+	Error *errors.Error
+}
+
+// Store is the storage of the idempotency records, e.g. Redis with SET NX.
+type Store interface {
+	// Reserve stores the record if the key is absent and returns nil,
+	// otherwise returns the existing record.
+	Reserve(ctx context.Context, key string, record *Record, ttl time.Duration) (existing *Record, err error)
+	// Save replaces the record of the key.
+	Save(ctx context.Context, key string, record *Record, ttl time.Duration) error
+	// Delete deletes the record of the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewMemoryStore returns an in-memory store keeps at most size records,
+// the least recently used records are evicted.
+func NewMemoryStore(size int) Store {
+	return &memoryStore{
+		size:  size,
+		items: make(map[string]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+type memoryStore struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	lru   *list.List
+}
+
+type memoryItem struct {
+	key      string
+	record   *Record
+	expireAt time.Time
+}
+
+func (s *memoryStore) Reserve(_ context.Context, key string, record *Record, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[key]; ok {
+		item := e.Value.(*memoryItem)
+		if time.Now().Before(item.expireAt) {
+			s.lru.MoveToFront(e)
+			return item.record, nil
+		}
+	}
+	s.set(key, record, ttl)
+	return nil, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, key string, record *Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(key, record, ttl)
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[key]; ok {
+		s.lru.Remove(e)
+		delete(s.items, key)
+	}
+	return nil
+}
+
+func (s *memoryStore) set(key string, record *Record, ttl time.Duration) {
+	item := &memoryItem{key: key, record: record, expireAt: time.Now().Add(ttl)}
+	if e, ok := s.items[key]; ok {
+		e.Value = item
+		s.lru.MoveToFront(e)
+		return
+	}
+	s.items[key] = s.lru.PushFront(item)
+	for s.size > 0 && s.lru.Len() > s.size {
+		e := s.lru.Back()
+		s.lru.Remove(e)
+		delete(s.items, e.Value.(*memoryItem).key)
+	}
+}
@@ -0,0 +1,248 @@
+package logging
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/go-kratos/kratos/v2/internal/redact"
+	"github.com/go-kratos/kratos/v2/log"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// AccessFormat is the format of the access log.
+type AccessFormat int
+
+const (
+	// FormatJSON logs the access as structured key-value fields,
+	// which a JSON logger renders as one JSON object.
+	FormatJSON AccessFormat = iota
+	// FormatCommon logs the access as a line of the Common Log Format.
+	FormatCommon
+	// FormatCombined logs the access as a line of the Combined Log Format.
+	FormatCombined
+)
+
+// clfTimeLayout is the time layout of the Common Log Format.
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// AccessOption is access log option.
+type AccessOption func(*accessOptions)
+
+// defaultRedactedQuery is the query parameters redacted by default, they commonly carry credentials,
+// e.g. the token read by jwt.FromQuery.
+var defaultRedactedQuery = []string{
+	"access_token", "api_key", "apikey", "auth", "jwt", "key", "password", "secret", "sig", "signature", "token",
+}
+
+type accessOptions struct {
+	format   AccessFormat
+	skip     func(*http.Request) bool
+	proxies  []netip.Prefix
+	redacted map[string]struct{}
+	user     bool
+}
+
+// WithAccessFormat with the access log format, default is FormatJSON.
+func WithAccessFormat(format AccessFormat) AccessOption {
+	return func(o *accessOptions) {
+		o.format = format
+	}
+}
+
+// WithAccessSkipper with the func reports whether the request is not logged,
+// e.g. health checks.
+func WithAccessSkipper(skip func(*http.Request) bool) AccessOption {
+	return func(o *accessOptions) {
+		o.skip = skip
+	}
+}
+
+// WithAccessTrustedProxies with the trusted proxies, the client IP is taken from
+// the X-Forwarded-For header of the requests from them, see khttp.ClientIP.
+// By default the client IP is the remote address of the request.
+func WithAccessTrustedProxies(proxies ...netip.Prefix) AccessOption {
+	return func(o *accessOptions) {
+		o.proxies = proxies
+	}
+}
+
+// WithAccessRedactedQuery with the query parameters whose values are redacted in the logged URI,
+// in addition to the defaults, e.g. the param of jwt.FromQuery. The names are case-insensitive.
+func WithAccessRedactedQuery(params ...string) AccessOption {
+	return func(o *accessOptions) {
+		for _, p := range params {
+			o.redacted[strings.ToLower(p)] = struct{}{}
+		}
+	}
+}
+
+// WithAccessUser with the user of the basic authentication logged, it's not logged by default.
+func WithAccessUser() AccessOption {
+	return func(o *accessOptions) {
+		o.user = true
+	}
+}
+
+// AccessEntry is an HTTP access log entry.
+type AccessEntry struct {
+	Time         time.Time
+	Method       string
+	URI          string
+	PathTemplate string
+	Proto        string
+	Status       int
+	ClientIP     string
+	User         string
+	UserAgent    string
+	Referer      string
+	RequestSize  int64
+	ResponseSize int64
+	Latency      time.Duration
+}
+
+// AccessLog is an HTTP access log filter, it can be used as a server filter so
+// that all the handlers are covered, or as a route filter.
+// The server errors are logged at error level, others at info level. The values of the query
+// parameters carrying credentials are redacted, see WithAccessRedactedQuery.
+func AccessLog(logger log.Logger, opts ...AccessOption) khttp.FilterFunc {
+	o := accessOptions{redacted: make(map[string]struct{}, len(defaultRedactedQuery))}
+	for _, p := range defaultRedactedQuery {
+		o.redacted[p] = struct{}{}
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if o.skip != nil && o.skip(req) {
+				next.ServeHTTP(w, req)
+				return
+			}
+			startTime := time.Now()
+			rw := khttp.NewRecordingWriter(w)
+			body := khttp.CountBody(req)
+			next.ServeHTTP(rw, req)
+
+			entry := &AccessEntry{
+				Time:         startTime,
+				Method:       req.Method,
+				URI:          req.RequestURI,
+				PathTemplate: rw.PathTemplate(),
+				Proto:        req.Proto,
+				Status:       rw.Status(),
+				ClientIP:     khttp.ClientIP(req, o.proxies...),
+				UserAgent:    req.UserAgent(),
+				Referer:      req.Referer(),
+				RequestSize:  body.Count(),
+				ResponseSize: rw.Size(),
+				Latency:      time.Since(startTime),
+			}
+			if entry.PathTemplate == "" {
+				entry.PathTemplate = req.URL.Path
+				if route := mux.CurrentRoute(req); route != nil {
+					entry.PathTemplate, _ = route.GetPathTemplate()
+				}
+			}
+			if req.ContentLength > entry.RequestSize {
+				// the body may be left unread by the handler
+				entry.RequestSize = req.ContentLength
+			}
+			if entry.URI == "" {
+				entry.URI = req.URL.RequestURI()
+			}
+			entry.URI = o.redactURI(entry.URI)
+			if o.user {
+				if req.URL.User != nil {
+					entry.User = req.URL.User.Username()
+				} else if user, _, ok := req.BasicAuth(); ok {
+					entry.User = user
+				}
+			}
+			level := log.LevelInfo
+			if entry.Status >= http.StatusInternalServerError {
+				level = log.LevelError
+			}
+			log.NewHelper(log.WithContext(req.Context(), logger)).Log(level, o.keyvals(entry)...)
+		})
+	}
+}
+
+func (o *accessOptions) keyvals(e *AccessEntry) []interface{} {
+	switch o.format {
+	case FormatCommon:
+		return []interface{}{log.DefaultMessageKey, e.Common()}
+	case FormatCombined:
+		return []interface{}{log.DefaultMessageKey, e.Combined()}
+	}
+	return []interface{}{
+		"kind", "access",
+		"method", e.Method,
+		"uri", e.URI,
+		"path_template", e.PathTemplate,
+		"proto", e.Proto,
+		"status", e.Status,
+		"client_ip", e.ClientIP,
+		"user_agent", e.UserAgent,
+		"referer", e.Referer,
+		"request_size", e.RequestSize,
+		"response_size", e.ResponseSize,
+		"latency", e.Latency.Seconds(),
+	}
+}
+
+// redactURI returns the URI with the values of the redacted query parameters masked.
+func (o *accessOptions) redactURI(uri string) string {
+	path, query, ok := strings.Cut(uri, "?")
+	if !ok || query == "" {
+		return uri
+	}
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		raw, _, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(raw)
+		if err != nil {
+			name = raw
+		}
+		if _, ok := o.redacted[strings.ToLower(name)]; ok {
+			pairs[i] = raw + "=" + redact.Value
+		}
+	}
+	return path + "?" + strings.Join(pairs, "&")
+}
+
+// Common returns the entry in the Common Log Format.
+func (e *AccessEntry) Common() string {
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s",
+		orDash(e.ClientIP),
+		orDash(e.User),
+		e.Time.Format(clfTimeLayout),
+		e.Method, e.URI, e.Proto,
+		e.Status,
+		sizeOrDash(e.ResponseSize),
+	)
+}
+
+// Combined returns the entry in the Combined Log Format.
+func (e *AccessEntry) Combined() string {
+	return fmt.Sprintf("%s %q %q", e.Common(), orDash(e.Referer), orDash(e.UserAgent))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func sizeOrDash(n int64) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+func TestAccessLog(t *testing.T) {
+	bf := bytes.NewBuffer(nil)
+	srv := khttp.NewServer(khttp.Filter(AccessLog(log.NewStdLogger(bf), WithAccessTrustedProxies(netip.MustParsePrefix("192.0.2.0/24")))))
+	srv.Route("/").POST("/users/{id}", func(ctx khttp.Context) error {
+		return ctx.String(http.StatusCreated, "created")
+	})
+	srv.HandleFunc("/raw", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/123?a=b", strings.NewReader("hello"))
+	req.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+	req.Header.Set("User-Agent", "kratos-test")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+	out := bf.String()
+	for _, field := range []string{
+		"INFO", "kind=access", "method=POST", "uri=/users/123?a=b", "path_template=/users/{id}",
+		"status=201", "client_ip=10.0.0.2", "user_agent=kratos-test", "request_size=5", "response_size=7",
+	} {
+		if !strings.Contains(out, field) {
+			t.Errorf("expect %q in %q", field, out)
+		}
+	}
+
+	bf.Reset()
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/raw", nil))
+	out = bf.String()
+	if !strings.Contains(out, "ERROR") || !strings.Contains(out, "status=500") || !strings.Contains(out, "path_template=/raw") {
+		t.Errorf("unexpected log %q", out)
+	}
+}
+
+func TestAccessLogFormat(t *testing.T) {
+	bf := bytes.NewBuffer(nil)
+	h := AccessLog(log.NewStdLogger(bf), WithAccessFormat(FormatCombined))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	req.SetBasicAuth("frank", "secret")
+	req.Header.Set("Referer", "http://example.com/")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	out := bf.String()
+	for _, part := range []string{
+		`192.168.1.1 - - [`, `] "GET /hello HTTP/1.1" 200 5 "http://example.com/" "-"`,
+	} {
+		if !strings.Contains(out, part) {
+			t.Errorf("expect %q in %q", part, out)
+		}
+	}
+
+	// the user is opt-in
+	bf.Reset()
+	h = AccessLog(log.NewStdLogger(bf), WithAccessFormat(FormatCommon), WithAccessUser())(http.NotFoundHandler())
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if out = bf.String(); !strings.Contains(out, `192.168.1.1 - frank [`) {
+		t.Errorf("expect the user logged, got %q", out)
+	}
+
+	bf.Reset()
+	h = AccessLog(log.NewStdLogger(bf), WithAccessFormat(FormatCommon), WithAccessSkipper(func(r *http.Request) bool {
+		return r.URL.Path == "/healthz"
+	}))(http.NotFoundHandler())
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if bf.Len() != 0 {
+		t.Errorf("expect skipped, got %q", bf.String())
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if out = bf.String(); !strings.Contains(out, `"GET /missing HTTP/1.1" 404 19`) || strings.Contains(out, "example.com") {
+		t.Errorf("unexpected log %q", out)
+	}
+}
+
+func TestAccessLogClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []netip.Prefix
+		ip      string
+	}{
+		{"untrusted", nil, "client_ip=192.0.2.1"},
+		{"trusted", []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}, "client_ip=10.0.0.2"},
+		{"other proxies", []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")}, "client_ip=192.0.2.1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bf := bytes.NewBuffer(nil)
+			h := AccessLog(log.NewStdLogger(bf), WithAccessTrustedProxies(test.proxies...))(http.NotFoundHandler())
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if out := bf.String(); !strings.Contains(out, test.ip) {
+				t.Errorf("expect %q in %q", test.ip, out)
+			}
+		})
+	}
+}
+
+func TestAccessLogRedactedQuery(t *testing.T) {
+	bf := bytes.NewBuffer(nil)
+	h := AccessLog(log.NewStdLogger(bf), WithAccessRedactedQuery("X-Session"))(http.NotFoundHandler())
+	req := httptest.NewRequest(http.MethodGet, "/ws?access_token=t1&page=2&x-session=s1&Token=t2&flag", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	out := bf.String()
+	if !strings.Contains(out, "uri=/ws?access_token=******&page=2&x-session=******&Token=******&flag") {
+		t.Errorf("expect the query redacted, got %q", out)
+	}
+	for _, secret := range []string{"t1", "t2", "s1"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expect %q redacted in %q", secret, out)
+		}
+	}
+}
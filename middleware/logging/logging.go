@@ -3,9 +3,14 @@ package logging
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"time"
 
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
 	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/redact"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
@@ -16,8 +21,106 @@ type Redacter interface {
 	Redact() string
 }
 
+// Option is logging option.
+type Option func(*options)
+
+type options struct {
+	redactor   *redact.Redactor
+	reply      bool
+	maxSize    int
+	sampleRate float64
+	operations map[string]float64
+}
+
+// WithRedactFields with the dot-separated proto field paths to redact, e.g. "user.password".
+// Fields annotated with the debug_redact option are always redacted.
+func WithRedactFields(paths ...string) Option {
+	return func(o *options) {
+		o.redactor.AddPaths(paths...)
+	}
+}
+
+// WithSensitiveExtension with the bool field option marks a proto field sensitive,
+// e.g. a custom `(sensitive) = true` annotation.
+func WithSensitiveExtension(xt protoreflect.ExtensionType) Option {
+	return func(o *options) {
+		o.redactor.SetExtension(xt)
+	}
+}
+
+// WithReply with logging the reply.
+func WithReply() Option {
+	return func(o *options) {
+		o.reply = true
+	}
+}
+
+// WithMaxSize with the max size of the logged args and reply, zero value means no limit.
+func WithMaxSize(size int) Option {
+	return func(o *options) {
+		o.maxSize = size
+	}
+}
+
+// WithSampleRate with the sampling rate in [0, 1] of the successful requests,
+// the failed requests are always logged.
+func WithSampleRate(rate float64) Option {
+	return func(o *options) {
+		o.sampleRate = rate
+	}
+}
+
+// WithOperationSampleRate with the sampling rate of the successful requests of the operation,
+// it overrides the default sampling rate.
+func WithOperationSampleRate(operation string, rate float64) Option {
+	return func(o *options) {
+		o.operations[operation] = rate
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		redactor:   redact.New(),
+		sampleRate: 1,
+		operations: make(map[string]float64),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// sampled reports whether the request should be logged.
+func (o *options) sampled(operation string, err error) bool {
+	if err != nil {
+		return true
+	}
+	rate, ok := o.operations[operation]
+	if !ok {
+		rate = o.sampleRate
+	}
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+// format returns the redacted and truncated string of the req or reply.
+func (o *options) format(v interface{}) string {
+	if v == nil {
+		// the whole stream has no request and reply
+		return ""
+	}
+	if m, ok := v.(proto.Message); ok {
+		v = o.redactor.Redact(m)
+	}
+	str := extractArgs(v)
+	if o.maxSize > 0 {
+		str = redact.Truncate(str, o.maxSize, "...(truncated)")
+	}
+	return str
+}
+
 // Server is an server logging middleware.
-func Server(logger log.Logger) middleware.Middleware {
+func Server(logger log.Logger, opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
 			var (
@@ -36,24 +139,32 @@ func Server(logger log.Logger) middleware.Middleware {
 				code = se.Code
 				reason = se.Reason
 			}
+			if !o.sampled(operation, err) {
+				return
+			}
 			level, stack := extractError(err)
-			log.NewHelper(log.WithContext(ctx, logger)).Log(level,
+			keyvals := []interface{}{
 				"kind", "server",
 				"component", kind,
 				"operation", operation,
-				"args", extractArgs(req),
+				"args", o.format(req),
 				"code", code,
 				"reason", reason,
 				"stack", stack,
 				"latency", time.Since(startTime).Seconds(),
-			)
+			}
+			if o.reply && err == nil {
+				keyvals = append(keyvals, "reply", o.format(reply))
+			}
+			log.NewHelper(log.WithContext(ctx, logger)).Log(level, keyvals...)
 			return
 		}
 	}
 }
 
 // Client is a client logging middleware.
-func Client(logger log.Logger) middleware.Middleware {
+func Client(logger log.Logger, opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
 			var (
@@ -72,17 +183,24 @@ func Client(logger log.Logger) middleware.Middleware {
 				code = se.Code
 				reason = se.Reason
 			}
+			if !o.sampled(operation, err) {
+				return
+			}
 			level, stack := extractError(err)
-			log.NewHelper(log.WithContext(ctx, logger)).Log(level,
+			keyvals := []interface{}{
 				"kind", "client",
 				"component", kind,
 				"operation", operation,
-				"args", extractArgs(req),
+				"args", o.format(req),
 				"code", code,
 				"reason", reason,
 				"stack", stack,
 				"latency", time.Since(startTime).Seconds(),
-			)
+			}
+			if o.reply && err == nil {
+				keyvals = append(keyvals, "reply", o.format(reply))
+			}
+			log.NewHelper(log.WithContext(ctx, logger)).Log(level, keyvals...)
 			return
 		}
 	}
@@ -46,7 +46,7 @@ func TestHTTP(t *testing.T) {
 
 	tests := []struct {
 		name string
-		kind func(logger log.Logger) middleware.Middleware
+		kind func(logger log.Logger, opts ...Option) middleware.Middleware
 		err  error
 		ctx  context.Context
 	}{
@@ -233,3 +233,32 @@ func TestClient_CallerPath(t *testing.T) {
 		t.Fatalf("middleware should have the same caller as log.Helper. middleware: %s, helper: %s", a[0][1], a[1][1])
 	}
 }
+
+var errTest = errors.New("test error")
+
+func TestReplyAndSampling(t *testing.T) {
+	var a extractKeyValues
+	ctx := transport.NewServerContext(context.Background(), &Transport{kind: transport.KindGRPC, operation: "/package.service/method"})
+	ok := func(context.Context, interface{}) (interface{}, error) { return "reply", nil }
+	fail := func(context.Context, interface{}) (interface{}, error) { return nil, errTest }
+
+	h := Server(&a, WithReply(), WithSampleRate(0))
+	_, _ = h(ok)(ctx, "req")
+	if len(a) != 0 {
+		t.Fatalf("expect successful request not logged, got %v", a)
+	}
+	_, _ = h(fail)(ctx, "req")
+	if len(a) != 1 {
+		t.Fatalf("expect failed request logged, got %v", a)
+	}
+
+	a = a[:0]
+	_, _ = Client(&a, WithReply())(ok)(ctx, "req")
+	if len(a) != 1 {
+		t.Fatalf("expect 1 log, got %v", a)
+	}
+	kvs := a[0]
+	if kvs[len(kvs)-2] != "reply" || kvs[len(kvs)-1] != "reply" {
+		t.Errorf("expect reply logged, got %v", kvs)
+	}
+}
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/go-kratos/kratos/v2/internal/redact"
+	"github.com/go-kratos/kratos/v2/internal/testdata/complex"
+)
+
+func TestRedactFields(t *testing.T) {
+	o := newOptions([]Option{WithRedactFields("no_one", "simple.component", "age")})
+	req := &complex.Complex{
+		Id:     1,
+		NoOne:  "secret",
+		Age:    18,
+		Simple: &complex.Simple{Component: "token"},
+	}
+	got := o.format(req)
+	for _, secret := range []string{"secret", "token", "18"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("expect %q to be redacted, got %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, redact.Value) {
+		t.Errorf("expect redacted value, got %s", got)
+	}
+	if req.NoOne != "secret" || req.Simple.Component != "token" {
+		t.Errorf("expect the request to be untouched, got %v", req)
+	}
+}
+
+func TestRedactDebugRedact(t *testing.T) {
+	fieldOpts := &descriptorpb.FieldOptions{DebugRedact: proto.Bool(true)}
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("kratos/test/logging.proto"),
+		Package: proto.String("kratos.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("LoginRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:     proto.String("username"),
+					JsonName: proto.String("username"),
+					Number:   proto.Int32(1),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				},
+				{
+					Name:     proto.String("password"),
+					JsonName: proto.String("password"),
+					Number:   proto.Int32(2),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					Options:  fieldOpts,
+				},
+			},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := fd.Messages().ByName("LoginRequest")
+	req := dynamicpb.NewMessage(md)
+	req.Set(md.Fields().ByName("username"), protoreflect.ValueOfString("kratos"))
+	req.Set(md.Fields().ByName("password"), protoreflect.ValueOfString("p@ssw0rd"))
+
+	got := newOptions(nil).format(req)
+	if strings.Contains(got, "p@ssw0rd") || !strings.Contains(got, "kratos") {
+		t.Errorf("expect password redacted, got %s", got)
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	o := newOptions([]Option{WithMaxSize(4)})
+	if got := o.format("abcdefgh"); got != "abcd...(truncated)" {
+		t.Errorf("expect %s, got %s", "abcd...(truncated)", got)
+	}
+	if got := o.format("abc"); got != "abc" {
+		t.Errorf("expect %s, got %s", "abc", got)
+	}
+	// the multi-byte runes aren't split
+	if got := o.format("ab你好"); got != "ab...(truncated)" {
+		t.Errorf("expect %s, got %s", "ab...(truncated)", got)
+	}
+}
+
+func TestRedactRecursive(t *testing.T) {
+	// the path of the recursive Struct is checked at any depth
+	path := strings.Repeat("fields.struct_value.", 10) + "fields.string_value"
+	o := newOptions([]Option{WithRedactFields(path)})
+	v := structpb.NewStringValue("secret")
+	for i := 0; i < 10; i++ {
+		v = structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{"k": v}})
+	}
+	req := &structpb.Struct{Fields: map[string]*structpb.Value{"k": v}}
+	if got := o.format(req); strings.Contains(got, "secret") || !strings.Contains(got, redact.Value) {
+		t.Errorf("expect the deep field redacted, got %s", got)
+	}
+	if got := newOptions(nil).format(req); !strings.Contains(got, "secret") {
+		t.Errorf("expect the recursive message without sensitive fields untouched, got %s", got)
+	}
+}
+
+func TestSampled(t *testing.T) {
+	o := newOptions([]Option{
+		WithSampleRate(0),
+		WithOperationSampleRate("/helloworld.Greeter/SayHello", 1),
+	})
+	if o.sampled("/helloworld.Greeter/SayBye", nil) {
+		t.Error("expect successful request not sampled")
+	}
+	if !o.sampled("/helloworld.Greeter/SayBye", errTest) {
+		t.Error("expect failed request always sampled")
+	}
+	if !o.sampled("/helloworld.Greeter/SayHello", nil) {
+		t.Error("expect operation sampled")
+	}
+}
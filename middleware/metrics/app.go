@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/go-kratos/kratos/v2"
+)
+
+// RegisterApp registers the lifecycle gauges of the application to the meter:
+//
+//	gauge: app_registered{id, name, version}
+//	gauge: app_servers_started{id, name, version}
+func RegisterApp(meter metric.Meter, app *kratos.App) (metric.Registration, error) {
+	registered, err := meter.Int64ObservableGauge("app_registered",
+		metric.WithDescription("Whether the application is registered to the registry."))
+	if err != nil {
+		return nil, err
+	}
+	started, err := meter.Int64ObservableGauge("app_servers_started",
+		metric.WithDescription("The number of the started servers."), metric.WithUnit("{server}"))
+	if err != nil {
+		return nil, err
+	}
+	attrs := metric.WithAttributes(
+		attribute.String("id", app.ID()),
+		attribute.String("name", app.Name()),
+		attribute.String("version", app.Version()),
+	)
+	return meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		var v int64
+		if app.Registered() {
+			v = 1
+		}
+		o.ObserveInt64(registered, v, attrs)
+		o.ObserveInt64(started, int64(app.ServersStarted()), attrs)
+		return nil
+	}, registered, started)
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+type registrar struct{}
+
+func (registrar) Register(context.Context, *registry.ServiceInstance) error   { return nil }
+func (registrar) Deregister(context.Context, *registry.ServiceInstance) error { return nil }
+
+func TestRegisterApp(t *testing.T) {
+	disableExemplars(t)
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	collect := func() map[string]int64 {
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatal(err)
+		}
+		values := map[string]int64{}
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				for _, dp := range m.Data.(metricdata.Gauge[int64]).DataPoints {
+					values[m.Name] = dp.Value
+				}
+			}
+		}
+		return values
+	}
+	app := kratos.New(
+		kratos.Name("kratos"),
+		kratos.Server(http.NewServer(), grpc.NewServer()),
+		kratos.Registrar(registrar{}),
+		kratos.AfterStart(func(context.Context) error {
+			if values := collect(); values["app_registered"] != 1 || values["app_servers_started"] != 2 {
+				t.Errorf("unexpected lifecycle gauges %v", values)
+			}
+			return nil
+		}),
+	)
+	reg, err := RegisterApp(meter, app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := collect(); values["app_registered"] != 0 || values["app_servers_started"] != 0 {
+		t.Errorf("unexpected lifecycle gauges %v", values)
+	}
+	time.AfterFunc(time.Second, func() {
+		_ = app.Stop()
+	})
+	if err = app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if values := collect(); values["app_registered"] != 0 || values["app_servers_started"] != 0 {
+		t.Errorf("unexpected lifecycle gauges %v", values)
+	}
+	if err = reg.Unregister(); err != nil {
+		t.Fatal(err)
+	}
+	if values := collect(); len(values) != 0 {
+		t.Errorf("expect the gauges unregistered, got %v", values)
+	}
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// DefaultDeadlineExhaustedCounterName is the name of the counter of the requests rejected
+// because their deadline budget is exhausted.
+const DefaultDeadlineExhaustedCounterName = "server_requests_deadline_exhausted_total"
+
+// DeadlineExhaustedCounter returns the func counts the requests rejected because their deadline budget
+// is exhausted as server_requests_deadline_exhausted_total{kind, operation}, for the DeadlineExhaustedHandler
+// options of the servers.
+func DeadlineExhaustedCounter(meter metric.Meter) (transport.DeadlineExhaustedFunc, error) {
+	counter, err := meter.Int64Counter(
+		DefaultDeadlineExhaustedCounterName,
+		metric.WithDescription("Number of the requests rejected because their deadline budget is exhausted."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, kind transport.Kind, operation string) {
+		counter.Add(ctx, 1, metric.WithAttributes(
+			attribute.String(metricLabelKind, kind.String()),
+			attribute.String(metricLabelOperation, operation),
+		))
+	}, nil
+}
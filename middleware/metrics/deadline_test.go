@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+func TestDeadlineExhaustedCounter(t *testing.T) {
+	disableExemplars(t)
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	exhausted, err := DeadlineExhaustedCounter(meter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := khttp.NewServer(khttp.DeadlinePropagation(), khttp.DeadlineExhaustedHandler(exhausted))
+	srv.Route("/").GET("/budget", func(ctx khttp.Context) error {
+		return ctx.String(http.StatusOK, "ok")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/budget", nil)
+	req.Header.Set(khttp.TimeoutHeader, "0s")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("expect %v, got %v", http.StatusGatewayTimeout, rec.Code)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err = reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	if len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 1 {
+		t.Fatalf("expect 1 exhausted request, got %+v", sum.DataPoints)
+	}
+	if operation, _ := sum.DataPoints[0].Attributes.Value(metricLabelOperation); operation.AsString() != "/budget" {
+		t.Errorf("expect %v, got %v", "/budget", operation.AsString())
+	}
+}
@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+const (
+	metricLabelMethod = "method"
+	metricLabelRoute  = "route"
+
+	// unmatchedRoute is the route label of the requests matched no route, e.g. 404 and 405.
+	unmatchedRoute = "unmatched"
+)
+
+const (
+	DefaultHTTPServerSecondsHistogramName     = "http_server_requests_seconds_bucket"
+	DefaultHTTPServerRequestsCounterName      = "http_server_requests_code_total"
+	DefaultHTTPServerInFlightName             = "http_server_requests_in_flight"
+	DefaultHTTPServerRequestSizeHistogramName = "http_server_request_size_bytes"
+	DefaultHTTPServerReplySizeHistogramName   = "http_server_response_size_bytes"
+)
+
+// WithInFlight with in-flight requests gauge, only used by Filter.
+func WithInFlight(c metric.Int64UpDownCounter) Option {
+	return func(o *options) {
+		o.inFlight = c
+	}
+}
+
+// WithRequestSize with request body size histogram, only used by Filter.
+func WithRequestSize(histogram metric.Int64Histogram) Option {
+	return func(o *options) {
+		o.requestSize = histogram
+	}
+}
+
+// WithReplySize with response body size histogram, only used by Filter.
+func WithReplySize(histogram metric.Int64Histogram) Option {
+	return func(o *options) {
+		o.replySize = histogram
+	}
+}
+
+// DefaultInFlightUpDownCounter
+// return metric.Int64UpDownCounter for WithInFlight
+// suggest name = http_server_requests_in_flight
+func DefaultInFlightUpDownCounter(meter metric.Meter, name string) (metric.Int64UpDownCounter, error) {
+	return meter.Int64UpDownCounter(name, metric.WithUnit("{request}"))
+}
+
+// DefaultSizeHistogram
+// return metric.Int64Histogram for WithRequestSize and WithReplySize
+// suggest name = http_server_<request/response>_size_bytes
+func DefaultSizeHistogram(meter metric.Meter, name string) (metric.Int64Histogram, error) {
+	return meter.Int64Histogram(name, metric.WithUnit("By"))
+}
+
+// DefaultHTTPServerOptions returns the options of the default instrument set of Filter.
+func DefaultHTTPServerOptions(meter metric.Meter) ([]Option, error) {
+	requests, err := DefaultRequestsCounter(meter, DefaultHTTPServerRequestsCounterName)
+	if err != nil {
+		return nil, err
+	}
+	seconds, err := DefaultSecondsHistogram(meter, DefaultHTTPServerSecondsHistogramName)
+	if err != nil {
+		return nil, err
+	}
+	inFlight, err := DefaultInFlightUpDownCounter(meter, DefaultHTTPServerInFlightName)
+	if err != nil {
+		return nil, err
+	}
+	requestSize, err := DefaultSizeHistogram(meter, DefaultHTTPServerRequestSizeHistogramName)
+	if err != nil {
+		return nil, err
+	}
+	replySize, err := DefaultSizeHistogram(meter, DefaultHTTPServerReplySizeHistogramName)
+	if err != nil {
+		return nil, err
+	}
+	return []Option{
+		WithRequests(requests),
+		WithSeconds(seconds),
+		WithInFlight(inFlight),
+		WithRequestSize(requestSize),
+		WithReplySize(replySize),
+	}, nil
+}
+
+// Filter is HTTP filter server-side metrics, it covers the requests never reach the middleware,
+// e.g. the handlers registered by Server.Handle and the unmatched routes.
+//
+//	counter: http_server_requests_code_total{method, route, code}
+//	histogram: http_server_requests_seconds_bucket{method, route}
+//	gauge: http_server_requests_in_flight{method}
+//	histogram: http_server_<request/response>_size_bytes{method, route}
+func Filter(opts ...Option) khttp.FilterFunc {
+	op := options{}
+	for _, o := range opts {
+		o(&op)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			if op.inFlight != nil {
+				inFlightAttrs := metric.WithAttributes(attribute.String(metricLabelMethod, req.Method))
+				op.inFlight.Add(ctx, 1, inFlightAttrs)
+				defer op.inFlight.Add(ctx, -1, inFlightAttrs)
+			}
+			startTime := time.Now()
+			rw := khttp.NewRecordingWriter(w)
+			body := khttp.CountBody(req)
+			next.ServeHTTP(rw, req)
+
+			route := rw.PathTemplate()
+			if route == "" {
+				route = unmatchedRoute
+				if r := mux.CurrentRoute(req); r != nil {
+					route, _ = r.GetPathTemplate()
+				}
+			}
+			attrs := []attribute.KeyValue{
+				attribute.String(metricLabelMethod, req.Method),
+				attribute.String(metricLabelRoute, route),
+			}
+			if op.requests != nil {
+				op.requests.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.Int(metricLabelCode, rw.Status()))...))
+			}
+			if op.seconds != nil {
+				op.seconds.Record(ctx, time.Since(startTime).Seconds(), metric.WithAttributes(attrs...))
+			}
+			if op.requestSize != nil {
+				size := body.Count()
+				if req.ContentLength > size {
+					// the body may be left unread by the handler
+					size = req.ContentLength
+				}
+				op.requestSize.Record(ctx, size, metric.WithAttributes(attrs...))
+			}
+			if op.replySize != nil {
+				op.replySize.Record(ctx, rw.Size(), metric.WithAttributes(attrs...))
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware/logging"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+func TestFilter(t *testing.T) {
+	disableExemplars(t)
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	opts, err := DefaultHTTPServerOptions(meter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := khttp.NewServer(khttp.Filter(Filter(opts...)))
+	srv.Route("/").POST("/users/{id}", func(ctx khttp.Context) error {
+		return ctx.String(http.StatusOK, "ok")
+	})
+	srv.HandleFunc("/raw", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	for _, path := range []string{"/users/1", "/users/2", "/raw", "/missing"} {
+		resp, err := http.Post(ts.URL+path, "text/plain", strings.NewReader("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err = reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]metricdata.Aggregation{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		got[m.Name] = m.Data
+	}
+
+	requests := got[DefaultHTTPServerRequestsCounterName].(metricdata.Sum[int64])
+	counts := map[string]int64{}
+	for _, dp := range requests.DataPoints {
+		route, _ := dp.Attributes.Value(metricLabelRoute)
+		code, _ := dp.Attributes.Value(metricLabelCode)
+		counts[route.AsString()+" "+code.Emit()] = dp.Value
+	}
+	expected := map[string]int64{"/users/{id} 200": 2, "/raw 418": 1, "unmatched 404": 1}
+	for key, n := range expected {
+		if counts[key] != n {
+			t.Errorf("%s: expect %v, got %v", key, n, counts[key])
+		}
+	}
+	if len(counts) != len(expected) {
+		t.Errorf("unexpected requests %v", counts)
+	}
+
+	inFlight := got[DefaultHTTPServerInFlightName].(metricdata.Sum[int64])
+	if len(inFlight.DataPoints) != 1 || inFlight.DataPoints[0].Value != 0 {
+		t.Errorf("expect no requests in flight, got %v", inFlight.DataPoints)
+	}
+	seconds := got[DefaultHTTPServerSecondsHistogramName].(metricdata.Histogram[float64])
+	if len(seconds.DataPoints) != 3 {
+		t.Errorf("expect %v routes, got %v", 3, len(seconds.DataPoints))
+	}
+	set := attribute.NewSet(attribute.String(metricLabelMethod, http.MethodPost), attribute.String(metricLabelRoute, "/users/{id}"))
+	for name, sum := range map[string]int64{DefaultHTTPServerRequestSizeHistogramName: 10, DefaultHTTPServerReplySizeHistogramName: 4} {
+		for _, dp := range got[name].(metricdata.Histogram[int64]).DataPoints {
+			if dp.Attributes.Equals(&set) && dp.Sum != sum {
+				t.Errorf("%s: expect %v, got %v", name, sum, dp.Sum)
+			}
+		}
+	}
+}
+
+func TestFilterStacked(t *testing.T) {
+	disableExemplars(t)
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	opts, err := DefaultHTTPServerOptions(meter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bf := bytes.NewBuffer(nil)
+	access := logging.AccessLog(log.NewStdLogger(bf))
+	for _, filters := range [][]khttp.FilterFunc{{access, Filter(opts...)}, {Filter(opts...), access}} {
+		srv := khttp.NewServer(khttp.Filter(filters...))
+		srv.Route("/").GET("/users/{id}", func(ctx khttp.Context) error {
+			return ctx.String(http.StatusOK, "ok")
+		})
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	}
+	if n := strings.Count(bf.String(), "path_template=/users/{id}"); n != 2 {
+		t.Errorf("expect the path template logged twice, got %q", bf.String())
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err = reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		if m.Name != DefaultHTTPServerRequestsCounterName {
+			continue
+		}
+		for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+			if route, _ := dp.Attributes.Value(metricLabelRoute); route.AsString() != "/users/{id}" || dp.Value != 2 {
+				t.Errorf("expect %v requests of the route, got %v of %v", 2, dp.Value, route.AsString())
+			}
+		}
+	}
+}
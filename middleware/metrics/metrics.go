@@ -90,6 +90,12 @@ type options struct {
 	requests metric.Int64Counter
 	// histogram: <client/server>_requests_seconds_bucket{kind, operation}
 	seconds metric.Float64Histogram
+	// gauge: http_server_requests_in_flight{method}
+	inFlight metric.Int64UpDownCounter
+	// histogram: http_server_request_size_bytes{method, route}
+	requestSize metric.Int64Histogram
+	// histogram: http_server_response_size_bytes{method, route}
+	replySize metric.Int64Histogram
 }
 
 // Server is middleware server-side metrics.
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// DefaultScrapePath is the default path of the Prometheus scrape endpoint.
+const DefaultScrapePath = "/metrics"
+
+// PrometheusExporter is a metric reader serves the collected metrics in the Prometheus exposition format,
+// by the OpenTelemetry Prometheus exporter with a registry of its own.
+// e.g.
+// exporter, err := NewPrometheusExporter()
+// mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(exporter))
+// srv.Handle("/metrics", exporter)
+type PrometheusExporter struct {
+	*otelprom.Exporter
+	handler http.Handler
+}
+
+// NewPrometheusExporter returns a new Prometheus exporter.
+func NewPrometheusExporter(opts ...otelprom.Option) (*PrometheusExporter, error) {
+	registry := prometheus.NewRegistry()
+	exporter, err := otelprom.New(append(opts, otelprom.WithRegisterer(registry))...)
+	if err != nil {
+		return nil, err
+	}
+	return &PrometheusExporter{
+		Exporter: exporter,
+		handler:  promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+	}, nil
+}
+
+// ServeHTTP collects and writes the metrics.
+func (e *PrometheusExporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	e.handler.ServeHTTP(w, req)
+}
+
+// NewPrometheusMeterProvider returns a meter provider exported by a new Prometheus exporter,
+// with the Go runtime and process metrics registered. The global meter provider is left
+// to the caller, e.g.
+// mp, exporter, err := NewPrometheusMeterProvider()
+// otel.SetMeterProvider(mp)
+// srv := khttp.NewServer(Scrape(DefaultScrapePath, exporter))
+func NewPrometheusMeterProvider(opts ...metricsdk.Option) (*metricsdk.MeterProvider, *PrometheusExporter, error) {
+	exporter, err := NewPrometheusExporter()
+	if err != nil {
+		return nil, nil, err
+	}
+	mp := metricsdk.NewMeterProvider(append(opts, metricsdk.WithReader(exporter))...)
+	if _, err = RegisterRuntime(mp.Meter("github.com/go-kratos/kratos/v2/middleware/metrics")); err != nil {
+		return nil, nil, err
+	}
+	return mp, exporter, nil
+}
+
+// Scrape returns a server option mounts the Prometheus scrape endpoint of the exporter at path.
+func Scrape(path string, exporter *PrometheusExporter) khttp.ServerOption {
+	return func(s *khttp.Server) {
+		s.Handle(path, exporter)
+	}
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/selector/random"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// disableExemplars disables the exemplars of the meter providers built by the test, as the exemplar
+// reservoirs of the sdk share a random source which races with the periodic reader set up in init.
+func disableExemplars(t *testing.T) {
+	t.Setenv("OTEL_METRICS_EXEMPLAR_FILTER", "always_off")
+}
+
+func TestPrometheusExporter(t *testing.T) {
+	disableExemplars(t)
+	exporter, err := NewPrometheusExporter(otelprom.WithoutScopeInfo(), otelprom.WithoutTargetInfo())
+	if err != nil {
+		t.Fatal(err)
+	}
+	meter := metricsdk.NewMeterProvider(metricsdk.WithReader(exporter)).Meter("test")
+	counter, _ := meter.Int64Counter("requests_total", metric.WithDescription("The requests."))
+	counter.Add(context.Background(), 2, metric.WithAttributes(attribute.String("path", `/a"b`)))
+	gauge, _ := meter.Float64UpDownCounter("queue.size")
+	gauge.Add(context.Background(), 1.5)
+	histogram, _ := meter.Float64Histogram("latency", metric.WithExplicitBucketBoundaries(0.1, 1))
+	histogram.Record(context.Background(), 0.5)
+	histogram.Record(context.Background(), 2)
+
+	rec := httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DefaultScrapePath, nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expect the text format, got %v", ct)
+	}
+	expected := `# HELP latency 
+# TYPE latency histogram
+latency_bucket{le="0.1"} 0
+latency_bucket{le="1"} 1
+latency_bucket{le="+Inf"} 2
+latency_sum 2.5
+latency_count 2
+# HELP queue_size 
+# TYPE queue_size gauge
+queue_size 1.5
+# HELP requests_total The requests.
+# TYPE requests_total counter
+requests_total{path="/a\"b"} 2
+`
+	if got := rec.Body.String(); got != expected {
+		t.Errorf("expect %q, got %q", expected, got)
+	}
+}
+
+func TestScrape(t *testing.T) {
+	disableExemplars(t)
+	mp, exporter, err := NewPrometheusMeterProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := khttp.NewServer(Scrape(DefaultScrapePath, exporter))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	builder, err := ObserveSelector(mp.Meter("test"), random.NewBuilder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ins := &registry.ServiceInstance{Name: "helloworld"}
+	selectors := make([]selector.Selector, 2)
+	for i := range selectors {
+		selectors[i] = builder.Build()
+		selectors[i].Apply([]selector.Node{
+			selector.NewNode("http", "127.0.0.1:8000", ins),
+			selector.NewNode("http", "127.0.0.1:8001", ins),
+		})
+	}
+
+	resp, err := http.Get(ts.URL + DefaultScrapePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	runtime.KeepAlive(selectors)
+	for _, line := range []string{"# TYPE go_goroutines gauge", "# TYPE go_gc_cycles_total counter", "process_uptime_seconds ", `target="helloworld"} 4`} {
+		if !strings.Contains(string(b), line) {
+			t.Errorf("expect %q in %s", line, b)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// processStartTime approximates the start time of the process by the package initialization.
+var processStartTime = time.Now()
+
+// RegisterRuntime registers the Go runtime and process metrics to the meter:
+//
+//	gauge: go_goroutines, go_gomaxprocs
+//	gauge: go_memstats_alloc_bytes, go_memstats_sys_bytes, go_memstats_heap_objects
+//	counter: go_gc_cycles_total, go_gc_pause_seconds_total
+//	gauge: process_start_time_seconds, process_uptime_seconds
+func RegisterRuntime(meter metric.Meter) (metric.Registration, error) {
+	goroutines, err := meter.Int64ObservableGauge("go_goroutines",
+		metric.WithDescription("Number of goroutines that currently exist."))
+	if err != nil {
+		return nil, err
+	}
+	maxProcs, err := meter.Int64ObservableGauge("go_gomaxprocs",
+		metric.WithDescription("The value of GOMAXPROCS."))
+	if err != nil {
+		return nil, err
+	}
+	alloc, err := meter.Int64ObservableGauge("go_memstats_alloc_bytes",
+		metric.WithDescription("Number of bytes allocated and still in use."), metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+	sys, err := meter.Int64ObservableGauge("go_memstats_sys_bytes",
+		metric.WithDescription("Number of bytes obtained from system."), metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+	heapObjects, err := meter.Int64ObservableGauge("go_memstats_heap_objects",
+		metric.WithDescription("Number of allocated objects."))
+	if err != nil {
+		return nil, err
+	}
+	gcCycles, err := meter.Int64ObservableCounter("go_gc_cycles_total",
+		metric.WithDescription("Number of completed GC cycles."))
+	if err != nil {
+		return nil, err
+	}
+	gcPause, err := meter.Float64ObservableCounter("go_gc_pause_seconds_total",
+		metric.WithDescription("Cumulative GC stop-the-world pause time."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	processStart, err := meter.Float64ObservableGauge("process_start_time_seconds",
+		metric.WithDescription("Start time of the process since unix epoch."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	uptime, err := meter.Float64ObservableGauge("process_uptime_seconds",
+		metric.WithDescription("Uptime of the process."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	return meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		o.ObserveInt64(goroutines, int64(runtime.NumGoroutine()))
+		o.ObserveInt64(maxProcs, int64(runtime.GOMAXPROCS(0)))
+		o.ObserveInt64(alloc, int64(ms.Alloc))
+		o.ObserveInt64(sys, int64(ms.Sys))
+		o.ObserveInt64(heapObjects, int64(ms.HeapObjects))
+		o.ObserveInt64(gcCycles, int64(ms.NumGC))
+		o.ObserveFloat64(gcPause, time.Duration(ms.PauseTotalNs).Seconds())
+		o.ObserveFloat64(processStart, float64(processStartTime.UnixNano())/1e9)
+		o.ObserveFloat64(uptime, time.Since(processStartTime).Seconds())
+		return nil
+	}, goroutines, maxProcs, alloc, sys, heapObjects, gcCycles, gcPause, processStart, uptime)
+}
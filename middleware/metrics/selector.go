@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+const metricLabelTarget = "target"
+
+// ObserveSelector returns a selector builder observes the node counts of the built selectors,
+// the gauge selector_nodes{target} is the sum of the nodes of the selectors of the target service.
+// e.g. selector.SetGlobalSelector(builder)
+func ObserveSelector(meter metric.Meter, builder selector.Builder) (selector.Builder, error) {
+	b := &observedBuilder{builder: builder}
+	nodes, err := meter.Int64ObservableGauge("selector_nodes",
+		metric.WithDescription("Number of the nodes applied to the selectors."), metric.WithUnit("{node}"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for target, n := range b.counts() {
+			o.ObserveInt64(nodes, n, metric.WithAttributes(attribute.String(metricLabelTarget, target)))
+		}
+		return nil
+	}, nodes); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+type observedBuilder struct {
+	builder selector.Builder
+	mu      sync.Mutex
+	states  map[*selectorState]struct{}
+}
+
+// Build returns a selector observed until it's closed or garbage collected,
+// e.g. the selectors of the replaced gRPC pickers.
+func (b *observedBuilder) Build() selector.Selector {
+	state := &selectorState{}
+	s := &observedSelector{Selector: b.builder.Build(), state: state, builder: b}
+	b.mu.Lock()
+	if b.states == nil {
+		b.states = make(map[*selectorState]struct{})
+	}
+	b.states[state] = struct{}{}
+	b.mu.Unlock()
+	// the builder only refers to the state, so the selector can be collected
+	runtime.SetFinalizer(s, (*observedSelector).Close)
+	return s
+}
+
+// counts returns the node counts of the built selectors by target.
+func (b *observedBuilder) counts() map[string]int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	counts := make(map[string]int64, len(b.states))
+	for s := range b.states {
+		if target, ok := s.target.Load().(string); ok {
+			counts[target] += s.nodes.Load()
+		}
+	}
+	return counts
+}
+
+type selectorState struct {
+	// target is the service name of the last applied nodes,
+	// it is kept when the nodes are cleared.
+	target atomic.Value
+	nodes  atomic.Int64
+}
+
+type observedSelector struct {
+	selector.Selector
+	state   *selectorState
+	builder *observedBuilder
+}
+
+func (s *observedSelector) Apply(nodes []selector.Node) {
+	if len(nodes) > 0 {
+		s.state.target.Store(nodes[0].ServiceName())
+	}
+	s.state.nodes.Store(int64(len(nodes)))
+	s.Selector.Apply(nodes)
+}
+
+// Close stops observing the selector, and closes the underlying selector if it's an io.Closer.
+func (s *observedSelector) Close() error {
+	s.builder.mu.Lock()
+	delete(s.builder.states, s.state)
+	s.builder.mu.Unlock()
+	if c, ok := s.Selector.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/selector/random"
+)
+
+func TestObserveSelector(t *testing.T) {
+	builder, err := ObserveSelector(noop.NewMeterProvider().Meter("test"), random.NewBuilder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := builder.(*observedBuilder)
+	ins := &registry.ServiceInstance{Name: "helloworld"}
+	apply := func(s selector.Selector) {
+		s.Apply([]selector.Node{
+			selector.NewNode("http", "127.0.0.1:8000", ins),
+			selector.NewNode("http", "127.0.0.1:8001", ins),
+		})
+	}
+	closed := builder.Build()
+	apply(closed)
+	apply(builder.Build())
+	if n := b.counts()["helloworld"]; n != 4 {
+		t.Errorf("expect %v, got %v", 4, n)
+	}
+	if err = closed.(*observedSelector).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.counts()["helloworld"]; n != 2 {
+		t.Errorf("expect %v, got %v", 2, n)
+	}
+	// the dropped selector is unregistered once collected
+	for i := 0; i < 50 && len(b.counts()) > 0; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if counts := b.counts(); len(counts) != 0 {
+		t.Errorf("expect the collected selector unregistered, got %v", counts)
+	}
+}
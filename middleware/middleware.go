@@ -19,3 +19,33 @@ func Chain(m ...Middleware) Middleware {
 		return next
 	}
 }
+
+// StreamCall is the call of a streaming RPC the middleware is invoked for.
+type StreamCall int
+
+const (
+	// StreamCallWhole is the call of the whole stream, it's invoked once with a nil request
+	// and returns when the stream finishes.
+	StreamCallWhole StreamCall = iota + 1
+	// StreamCallMessage is the call of a message sent or received on the stream.
+	StreamCallMessage
+)
+
+type streamKey struct{}
+
+// NewStreamContext returns a new Context that carries the stream call.
+func NewStreamContext(ctx context.Context, call StreamCall) context.Context {
+	return context.WithValue(ctx, streamKey{}, call)
+}
+
+// StreamFromContext returns the stream call stored in ctx, if any.
+func StreamFromContext(ctx context.Context) (call StreamCall, ok bool) {
+	call, ok = ctx.Value(streamKey{}).(StreamCall)
+	return
+}
+
+// IsStream reports whether ctx is the call of the whole stream.
+func IsStream(ctx context.Context) bool {
+	call, _ := StreamFromContext(ctx)
+	return call == StreamCallWhole
+}
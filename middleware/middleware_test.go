@@ -57,3 +57,17 @@ func test3Middleware(handler Handler) Handler {
 		return
 	}
 }
+
+func TestStreamContext(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := StreamFromContext(ctx); ok || IsStream(ctx) {
+		t.Errorf("expect no stream call")
+	}
+	if !IsStream(NewStreamContext(ctx, StreamCallWhole)) {
+		t.Errorf("expect the whole stream call")
+	}
+	call, ok := StreamFromContext(NewStreamContext(ctx, StreamCallMessage))
+	if !ok || call != StreamCallMessage {
+		t.Errorf("expect %v, got %v", StreamCallMessage, call)
+	}
+}
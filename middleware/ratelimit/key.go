@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+
+	"google.golang.org/grpc/peer"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// KeyFunc returns the rate limit key of the request, ok is false if the request is not limited.
+// The callers are identified by e.g. jwt.SubjectFromContext or apikey.IDFromContext.
+type KeyFunc func(ctx context.Context) (key string, ok bool)
+
+// ByOperation returns the operation of the request as the key.
+func ByOperation() KeyFunc {
+	return func(ctx context.Context) (string, bool) {
+		tr, ok := transport.FromServerContext(ctx)
+		if !ok {
+			return "", false
+		}
+		return tr.Operation(), true
+	}
+}
+
+// ByHeader returns the value of the request header as the key.
+func ByHeader(name string) KeyFunc {
+	return func(ctx context.Context) (string, bool) {
+		tr, ok := transport.FromServerContext(ctx)
+		if !ok {
+			return "", false
+		}
+		v := tr.RequestHeader().Get(name)
+		return v, v != ""
+	}
+}
+
+// ByClientIP returns the client IP of the request as the key. The X-Forwarded-For header is
+// only respected on HTTP if the remote address is one of the trusted proxies, see khttp.ClientIP.
+func ByClientIP(trustedProxies ...netip.Prefix) KeyFunc {
+	return func(ctx context.Context) (string, bool) {
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			if ht, ok := tr.(khttp.Transporter); ok {
+				ip := khttp.ClientIP(ht.Request(), trustedProxies...)
+				return ip, ip != ""
+			}
+		}
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return "", false
+		}
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String(), true
+		}
+		return host, true
+	}
+}
+
+// Keys returns the joined keys of the funcs, e.g. Keys(jwt.SubjectFromContext, ByOperation())
+// limits each caller per operation. The request is not limited if any key is absent.
+func Keys(funcs ...KeyFunc) KeyFunc {
+	return func(ctx context.Context) (string, bool) {
+		keys := make([]string, 0, len(funcs))
+		for _, f := range funcs {
+			key, ok := f(ctx)
+			if !ok {
+				return "", false
+			}
+			keys = append(keys, key)
+		}
+		return strings.Join(keys, "|"), true
+	}
+}
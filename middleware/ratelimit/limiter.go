@@ -0,0 +1,244 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the number of the calls between the sweeps of the idle keys.
+const sweepInterval = 1024
+
+// Result is the decision of a keyed limiter.
+type Result struct {
+	// Allowed reports whether the request is allowed.
+	Allowed bool
+	// Limit is the quota of the key.
+	Limit int
+	// Remaining is the remaining quota of the key.
+	Remaining int
+	// RetryAfter is the time until the request would be allowed, zero if allowed.
+	RetryAfter time.Duration
+	// Reset is the time until the quota is fully restored.
+	Reset time.Duration
+}
+
+// KeyLimiter limits the requests per key.
+type KeyLimiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// NewTokenBucket returns an in-memory token bucket limiter of each key, the bucket holds
+// at most burst tokens and is refilled with limit tokens per period.
+func NewTokenBucket(limit int, period time.Duration, burst int) KeyLimiter {
+	return &tokenBucket{
+		rate:    float64(limit) / period.Seconds(),
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+type tokenBucket struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) Allow(_ context.Context, key string) (Result, error) {
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sweep(now)
+	bk, ok := b.buckets[key]
+	if !ok {
+		bk = &bucket{tokens: b.burst, last: now}
+		b.buckets[key] = bk
+	}
+	bk.tokens = math.Min(b.burst, bk.tokens+now.Sub(bk.last).Seconds()*b.rate)
+	bk.last = now
+	res := Result{Limit: int(b.burst)}
+	if bk.tokens >= 1 {
+		bk.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = b.duration(1 - bk.tokens)
+	}
+	res.Remaining = int(bk.tokens)
+	res.Reset = b.duration(b.burst - bk.tokens)
+	return res, nil
+}
+
+// duration returns the time to refill the tokens.
+func (b *tokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / b.rate * float64(time.Second))
+}
+
+// sweep removes the full buckets periodically, as they are the same as the absent ones.
+func (b *tokenBucket) sweep(now time.Time) {
+	if b.calls++; b.calls < sweepInterval {
+		return
+	}
+	b.calls = 0
+	for key, bk := range b.buckets {
+		if bk.tokens+now.Sub(bk.last).Seconds()*b.rate >= b.burst {
+			delete(b.buckets, key)
+		}
+	}
+}
+
+// Store is the storage of the sliding window counters, e.g. Redis with INCR and EXPIRE.
+type Store interface {
+	// Incr increments the counter of the key and returns the new value,
+	// the counter expires after ttl since its creation.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Get returns the counter of the key, zero if absent.
+	Get(ctx context.Context, key string) (int64, error)
+}
+
+// SlidingWindowOption is sliding window limiter option.
+type SlidingWindowOption func(*slidingWindow)
+
+// WithStore with the store of the counters, default is an in-memory store,
+// a shared store makes the limit distributed across the instances.
+func WithStore(store Store) SlidingWindowOption {
+	return func(w *slidingWindow) {
+		w.store = store
+	}
+}
+
+// NewSlidingWindow returns a sliding window limiter allows limit requests per window of each key.
+// The window is approximated by the weighted counters of the current and the previous fixed windows,
+// only the allowed requests are counted, so a rejected client recovers once the window slides.
+// The counters are read before the increment, the concurrent requests of a shared store may exceed the limit slightly.
+func NewSlidingWindow(limit int, window time.Duration, opts ...SlidingWindowOption) KeyLimiter {
+	w := &slidingWindow{limit: limit, window: window, now: time.Now}
+	for _, o := range opts {
+		o(w)
+	}
+	if w.store == nil {
+		w.store = NewMemoryStore()
+	}
+	return w
+}
+
+type slidingWindow struct {
+	limit  int
+	window time.Duration
+	store  Store
+	now    func() time.Time
+}
+
+func (w *slidingWindow) Allow(ctx context.Context, key string) (Result, error) {
+	now := w.now()
+	index := now.UnixNano() / int64(w.window)
+	elapsed := time.Duration(now.UnixNano() - index*int64(w.window))
+	currKey := key + ":" + strconv.FormatInt(index, 10)
+	curr, err := w.store.Get(ctx, currKey)
+	if err != nil {
+		return Result{}, err
+	}
+	prev, err := w.store.Get(ctx, key+":"+strconv.FormatInt(index-1, 10))
+	if err != nil {
+		return Result{}, err
+	}
+	weight := float64(w.window-elapsed) / float64(w.window)
+	count := float64(prev)*weight + float64(curr)
+	res := Result{
+		// the request is allowed if the count including it doesn't exceed the limit
+		Allowed: count+1 <= float64(w.limit),
+		Limit:   w.limit,
+		Reset:   2*w.window - elapsed,
+	}
+	if res.Allowed {
+		if curr, err = w.store.Incr(ctx, currKey, 2*w.window); err != nil {
+			return Result{}, err
+		}
+		count = float64(prev)*weight + float64(curr)
+	} else {
+		res.RetryAfter = w.retryAfter(prev, curr, elapsed)
+	}
+	res.Remaining = int(math.Max(0, float64(w.limit)-math.Ceil(count)))
+	return res, nil
+}
+
+// retryAfter returns the time until the weighted count decays to admit one more request.
+func (w *slidingWindow) retryAfter(prev, curr int64, elapsed time.Duration) time.Duration {
+	quota := int64(w.limit - 1)
+	if curr <= quota && prev > 0 {
+		// the previous window decays below the remaining quota of the current window
+		return w.decay(prev, quota-curr) - elapsed
+	}
+	// the current window becomes the previous one and decays below the quota
+	return w.window - elapsed + w.decay(curr, quota)
+}
+
+// decay returns the time since the start of a window until the weighted count
+// of the previous window decays from count to quota.
+func (w *slidingWindow) decay(count, quota int64) time.Duration {
+	return time.Duration(math.Ceil(float64(w.window) * float64(count-quota) / float64(count)))
+}
+
+// NewMemoryStore returns an in-memory store of the counters.
+func NewMemoryStore() Store {
+	return &memoryStore{counters: make(map[string]*counter), now: time.Now}
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+	calls    int
+	now      func() time.Time
+}
+
+type counter struct {
+	value    int64
+	expireAt time.Time
+}
+
+func (s *memoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expireAt) {
+		c = &counter{expireAt: now.Add(ttl)}
+		s.counters[key] = c
+	}
+	c.value++
+	return c.value, nil
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.counters[key]; ok && s.now().Before(c.expireAt) {
+		return c.value, nil
+	}
+	return 0, nil
+}
+
+// sweep removes the expired counters periodically.
+func (s *memoryStore) sweep(now time.Time) {
+	if s.calls++; s.calls < sweepInterval {
+		return
+	}
+	s.calls = 0
+	for key, c := range s.counters {
+		if !now.Before(c.expireAt) {
+			delete(s.counters, key)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func testStore(clock *fakeClock) *memoryStore {
+	return &memoryStore{counters: make(map[string]*counter), now: clock.Now}
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := newFakeClock(time.Unix(0, 0))
+	l := NewTokenBucket(10, time.Second, 2).(*tokenBucket)
+	l.now = clock.Now
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if res, _ := l.Allow(ctx, "a"); !res.Allowed || res.Remaining != 1-i {
+			t.Errorf("expect allowed with %d remaining, got %+v", 1-i, res)
+		}
+	}
+	res, _ := l.Allow(ctx, "a")
+	if res.Allowed || res.RetryAfter != 100*time.Millisecond {
+		t.Errorf("expect rejected with retry after %v, got %+v", 100*time.Millisecond, res)
+	}
+	if res, _ = l.Allow(ctx, "b"); !res.Allowed {
+		t.Errorf("expect the other key allowed, got %+v", res)
+	}
+	clock.Add(50 * time.Millisecond)
+	if res, _ = l.Allow(ctx, "a"); res.Allowed || res.RetryAfter != 50*time.Millisecond {
+		t.Errorf("expect rejected with retry after %v, got %+v", 50*time.Millisecond, res)
+	}
+	clock.Add(50 * time.Millisecond)
+	if res, _ = l.Allow(ctx, "a"); !res.Allowed {
+		t.Errorf("expect allowed after refill, got %+v", res)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := newFakeClock(time.Unix(3600, 0))
+	l := NewSlidingWindow(3, time.Hour, WithStore(testStore(clock))).(*slidingWindow)
+	l.now = clock.Now
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if res, _ := l.Allow(ctx, "a"); !res.Allowed || res.Remaining != 2-i {
+			t.Errorf("expect allowed with %d remaining, got %+v", 2-i, res)
+		}
+	}
+	// the full window decays to 2 requests after 80 minutes
+	res, _ := l.Allow(ctx, "a")
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 80*time.Minute {
+		t.Errorf("expect rejected with retry after %v, got %+v", 80*time.Minute, res)
+	}
+	if res, _ = l.Allow(ctx, "b"); !res.Allowed {
+		t.Errorf("expect the other key allowed, got %+v", res)
+	}
+}
+
+func TestSlidingWindowRecovery(t *testing.T) {
+	clock := newFakeClock(time.Unix(3600, 0))
+	l := NewSlidingWindow(3, time.Hour, WithStore(testStore(clock))).(*slidingWindow)
+	l.now = clock.Now
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, _ = l.Allow(ctx, "a")
+	}
+	// the rejected requests of a retrying client aren't counted
+	var res Result
+	for i := 0; i < 10; i++ {
+		res, _ = l.Allow(ctx, "a")
+		if res.Allowed {
+			t.Fatalf("expect rejected, got %+v", res)
+		}
+	}
+	clock.Add(res.RetryAfter - time.Second)
+	if res, _ = l.Allow(ctx, "a"); res.Allowed {
+		t.Errorf("expect rejected before retry after, got %+v", res)
+	}
+	clock.Add(res.RetryAfter)
+	if res, _ = l.Allow(ctx, "a"); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("expect allowed after retry after, got %+v", res)
+	}
+	clock.Add(2 * time.Hour)
+	for i := 0; i < 3; i++ {
+		if res, _ = l.Allow(ctx, "a"); !res.Allowed {
+			t.Errorf("expect allowed after the window, got %+v", res)
+		}
+	}
+}
+
+func TestSlidingWindowWeighted(t *testing.T) {
+	clock := newFakeClock(time.Unix(3600, 0))
+	l := NewSlidingWindow(10, time.Hour, WithStore(testStore(clock))).(*slidingWindow)
+	l.now = clock.Now
+	ctx := context.Background()
+	// fill the previous window
+	for i := 0; i < 10; i++ {
+		_, _ = l.Allow(ctx, "a")
+	}
+	// the previous window weighs 1 - elapsed/window
+	clock.Add(time.Hour + 30*time.Minute)
+	var (
+		res     Result
+		allowed int
+	)
+	for i := 0; i < 6; i++ {
+		if res, _ = l.Allow(ctx, "a"); res.Allowed {
+			allowed++
+		}
+	}
+	if allowed != 5 {
+		t.Errorf("expect %d allowed, got %d", 5, allowed)
+	}
+	// the previous window decays to admit one more request of the current window after 36 minutes
+	if res.RetryAfter != 6*time.Minute {
+		t.Errorf("expect retry after %v, got %+v", 6*time.Minute, res)
+	}
+	clock.Add(res.RetryAfter)
+	if res, _ = l.Allow(ctx, "a"); !res.Allowed {
+		t.Errorf("expect allowed after retry after, got %+v", res)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	clock := newFakeClock(time.Unix(0, 0))
+	s := testStore(clock)
+	ctx := context.Background()
+	if v, _ := s.Incr(ctx, "a", 50*time.Millisecond); v != 1 {
+		t.Errorf("expect %v, got %v", 1, v)
+	}
+	if v, _ := s.Incr(ctx, "a", 50*time.Millisecond); v != 2 {
+		t.Errorf("expect %v, got %v", 2, v)
+	}
+	if v, _ := s.Get(ctx, "a"); v != 2 {
+		t.Errorf("expect %v, got %v", 2, v)
+	}
+	clock.Add(50 * time.Millisecond)
+	if v, _ := s.Get(ctx, "a"); v != 0 {
+		t.Errorf("expect the counter expired, got %v", v)
+	}
+	if v, _ := s.Incr(ctx, "a", 50*time.Millisecond); v != 1 {
+		t.Errorf("expect a new counter, got %v", v)
+	}
+}
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// PriorityKey is the metadata key of the request priority, it's propagated
+// to the downstream calls by the metadata client middleware.
+const PriorityKey = "x-md-global-priority"
+
+// Priority is the priority class of the request, the lower priorities are shed first.
+type Priority int
+
+const (
+	// PriorityLow is the priority of the work can be dropped, e.g. batch jobs and prefetches.
+	PriorityLow Priority = iota
+	// PriorityNormal is the default priority.
+	PriorityNormal
+	// PriorityHigh is the priority of the user-facing critical paths.
+	PriorityHigh
+	// PriorityCritical is the priority never shed, e.g. health checks.
+	PriorityCritical
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:      "low",
+	PriorityNormal:   "normal",
+	PriorityHigh:     "high",
+	PriorityCritical: "critical",
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParsePriority parses the priority of the name, e.g. "low".
+func ParsePriority(name string) (Priority, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for p, n := range priorityNames {
+		if n == name {
+			return p, true
+		}
+	}
+	return PriorityNormal, false
+}
+
+type priorityKey struct{}
+
+// NewPriorityContext returns a new Context that carries the priority,
+// and the priority is set to the server metadata for the propagation.
+func NewPriorityContext(ctx context.Context, p Priority) context.Context {
+	md, _ := metadata.FromServerContext(ctx)
+	md = md.Clone()
+	md.Set(PriorityKey, p.String())
+	ctx = metadata.NewServerContext(ctx, md)
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority in ctx if it exists.
+func PriorityFromContext(ctx context.Context) (Priority, bool) {
+	p, ok := ctx.Value(priorityKey{}).(Priority)
+	return p, ok
+}
+
+// PriorityFunc returns the priority of the request, ok is false if it's undetermined.
+type PriorityFunc func(ctx context.Context) (p Priority, ok bool)
+
+// PriorityByMetadata returns the priority propagated by the upstream, the metadata server middleware
+// must be placed before. The metadata is set by any client, so it's capped at PriorityHigh,
+// and PriorityCritical is only granted by the trusted sources, e.g. PriorityByOperation.
+func PriorityByMetadata() PriorityFunc {
+	return func(ctx context.Context) (Priority, bool) {
+		md, ok := metadata.FromServerContext(ctx)
+		if !ok {
+			return PriorityNormal, false
+		}
+		p, ok := ParsePriority(md.Get(PriorityKey))
+		if p > PriorityHigh {
+			p = PriorityHigh
+		}
+		return p, ok
+	}
+}
+
+// PriorityByOperation returns the priority of the operation, the pattern ends with '*'
+// matches the operations of the prefix, and the exact match is preferred over the longest prefix match.
+// e.g. map[string]Priority{"/grpc.health.v1.Health/*": PriorityCritical}
+func PriorityByOperation(priorities map[string]Priority) PriorityFunc {
+	return func(ctx context.Context) (Priority, bool) {
+		tr, ok := transport.FromServerContext(ctx)
+		if !ok {
+			return PriorityNormal, false
+		}
+		operation := tr.Operation()
+		if p, ok := priorities[operation]; ok {
+			return p, true
+		}
+		var (
+			priority = PriorityNormal
+			longest  = -1
+		)
+		for pattern, p := range priorities {
+			prefix := strings.TrimSuffix(pattern, "*")
+			if len(prefix) == len(pattern) || len(prefix) <= longest || !strings.HasPrefix(operation, prefix) {
+				continue
+			}
+			priority, longest = p, len(prefix)
+		}
+		return priority, longest >= 0
+	}
+}
+
+// PriorityByKey returns the priority of the caller identified by the key,
+// e.g. PriorityByKey(apikey.IDFromContext, map[string]Priority{"batch": PriorityLow}).
+func PriorityByKey(key KeyFunc, priorities map[string]Priority) PriorityFunc {
+	return func(ctx context.Context) (Priority, bool) {
+		k, ok := key(ctx)
+		if !ok {
+			return PriorityNormal, false
+		}
+		p, ok := priorities[k]
+		return p, ok
+	}
+}
@@ -2,12 +2,17 @@ package ratelimit
 
 import (
 	"context"
+	"math"
+	"strconv"
+	"time"
 
 	"github.com/go-kratos/aegis/ratelimit"
 	"github.com/go-kratos/aegis/ratelimit/bbr"
 
 	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
 )
 
 // ErrLimitExceed is service unavailable due to rate limit exceeded.
@@ -43,6 +48,11 @@ func Server(opts ...Option) middleware.Middleware {
 				// rejected
 				return nil, ErrLimitExceed
 			}
+			if middleware.IsStream(ctx) {
+				// the stream is only admitted, its lifetime isn't the response time of the server
+				done(ratelimit.DoneInfo{})
+				return handler(ctx, req)
+			}
 			// allowed
 			reply, err = handler(ctx, req)
 			done(ratelimit.DoneInfo{Err: err})
@@ -50,3 +60,41 @@ func Server(opts ...Option) middleware.Middleware {
 		}
 	}
 }
+
+// KeyServer is a server middleware limits the requests per key, e.g.
+// ratelimit.KeyServer(ratelimit.Keys(jwt.SubjectFromContext, ratelimit.ByOperation()), ratelimit.NewTokenBucket(100, time.Minute, 20)).
+// The requests without the key are not limited, and the requests are allowed if the limiter fails.
+// The RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and Retry-After headers are set on HTTP.
+func KeyServer(key KeyFunc, limiter KeyLimiter) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			k, ok := key(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			res, err := limiter.Allow(ctx, k)
+			if err != nil {
+				log.Context(ctx).Errorf("ratelimit: failed to limit the key %s: %v", k, err)
+				return handler(ctx, req)
+			}
+			if tr, ok := transport.FromServerContext(ctx); ok && tr.Kind() == transport.KindHTTP {
+				header := tr.ReplyHeader()
+				header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+				header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+				header.Set("RateLimit-Reset", seconds(res.Reset))
+				if !res.Allowed {
+					header.Set("Retry-After", seconds(res.RetryAfter))
+				}
+			}
+			if !res.Allowed {
+				return nil, ErrLimitExceed
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// seconds returns the duration in seconds rounded up.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/go-kratos/aegis/ratelimit"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
 )
 
 type (
@@ -62,3 +66,54 @@ func TestServer(t *testing.T) {
 		t.Error("The ratelimit must not run the done function and should be denied.")
 	}
 }
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string  { return hc[key] }
+func (hc headerCarrier) Set(key, value string)  { hc[key] = value }
+func (hc headerCarrier) Add(key, value string)  { hc[key] = value }
+func (hc headerCarrier) Keys() []string         { return nil }
+func (hc headerCarrier) Values(string) []string { return nil }
+
+type Transport struct {
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+func (tr *Transport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *Transport) Endpoint() string                { return "" }
+func (tr *Transport) Operation() string               { return "/helloworld.Greeter/SayHello" }
+func (tr *Transport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *Transport) ReplyHeader() transport.Header   { return tr.replyHeader }
+
+func TestKeyServer(t *testing.T) {
+	next := func(context.Context, interface{}) (interface{}, error) {
+		return "reply", nil
+	}
+	h := KeyServer(Keys(ByHeader("X-User"), ByOperation()), NewTokenBucket(1, time.Minute, 1))(next)
+	call := func(user string) (*Transport, error) {
+		tr := &Transport{reqHeader: headerCarrier{"X-User": user}, replyHeader: headerCarrier{}}
+		_, err := h(transport.NewServerContext(context.Background(), tr), nil)
+		return tr, err
+	}
+	tr, err := call("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.replyHeader["RateLimit-Limit"] != "1" || tr.replyHeader["RateLimit-Remaining"] != "0" || tr.replyHeader["Retry-After"] != "" {
+		t.Errorf("unexpected headers %v", tr.replyHeader)
+	}
+	tr, err = call("alice")
+	if !kerrors.Is(err, ErrLimitExceed) {
+		t.Errorf("expect %v, got %v", ErrLimitExceed, err)
+	}
+	if tr.replyHeader["Retry-After"] != "60" {
+		t.Errorf("expect Retry-After %v, got %v", "60", tr.replyHeader["Retry-After"])
+	}
+	if _, err = call("bob"); err != nil {
+		t.Errorf("expect the other key allowed, got %v", err)
+	}
+	if _, err = call(""); err != nil {
+		t.Errorf("expect the request without the key allowed, got %v", err)
+	}
+}
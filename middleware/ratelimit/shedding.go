@@ -0,0 +1,240 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit/bbr"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+)
+
+const (
+	// probeWindow is the window of the passes and the response times estimate the capacity.
+	probeWindow  = time.Second
+	probeBuckets = 10
+	// probeCoolDown is the time the requests are still probed after the CPU pressure.
+	probeCoolDown = time.Second
+)
+
+// sheddingRatios are the ratios of the thresholds the priorities are shed from.
+var sheddingRatios = map[Priority]float64{
+	PriorityLow:    0.8,
+	PriorityNormal: 0.9,
+	PriorityHigh:   1,
+}
+
+// ShedderOption is load shedder option.
+type ShedderOption func(*shedder)
+
+// WithPriority with the funcs resolve the priority of the request in order,
+// default is PriorityByMetadata(). Put the trusted sources first, as the metadata
+// comes from the upstream, e.g. WithPriority(PriorityByOperation(m), PriorityByMetadata()).
+// The metadata never grants PriorityCritical.
+func WithPriority(funcs ...PriorityFunc) ShedderOption {
+	return func(s *shedder) {
+		s.priorities = funcs
+	}
+}
+
+// WithDefaultPriority with the priority of the requests the funcs can't determine, default is PriorityNormal.
+func WithDefaultPriority(p Priority) ShedderOption {
+	return func(s *shedder) {
+		s.defaultPriority = p
+	}
+}
+
+// WithMaxInFlight with the threshold of the in-flight requests, default is 0 means unlimited.
+func WithMaxInFlight(n int64) ShedderOption {
+	return func(s *shedder) {
+		s.maxInFlight = n
+	}
+}
+
+// WithCPUThreshold with the threshold of the CPU usage in permille, default is 800.
+func WithCPUThreshold(threshold int64) ShedderOption {
+	return func(s *shedder) {
+		s.cpuThreshold = threshold
+	}
+}
+
+// WithCPUUsage with the func returns the CPU usage in permille, default is the usage sampled by aegis.
+func WithCPUUsage(f func() int64) ShedderOption {
+	return func(s *shedder) {
+		s.cpu = f
+	}
+}
+
+type shedder struct {
+	priorities      []PriorityFunc
+	defaultPriority Priority
+	maxInFlight     int64
+	cpuThreshold    int64
+	cpu             func() int64
+	now             func() time.Time
+
+	inFlight int64
+	dropped  [PriorityCritical]int64 // the unix nano of the last shed of the priorities under the CPU pressure
+	probe    probe
+}
+
+var (
+	cpuOnce    sync.Once
+	cpuLimiter *bbr.BBR
+)
+
+// cpuUsage returns the CPU usage sampled by aegis, its cpu package is internal
+// so the usage is read by the stat of a bbr limiter shared by the shedders.
+func cpuUsage() int64 {
+	cpuOnce.Do(func() {
+		cpuLimiter = bbr.NewLimiter()
+	})
+	return cpuLimiter.Stat().CPU
+}
+
+// Shedder is a server middleware sheds the requests by the priority under the CPU or in-flight pressure.
+// The low priority requests are shed from 80% of the thresholds, the normal from 90%, the high at the thresholds,
+// and the critical never. Under the CPU pressure the requests are probed like BBR rather than shed at once,
+// they're shed only if the in-flight requests exceed the ratios of the capacity estimated by the max passes
+// and the min response time of the last second, and the probing lasts a second after the pressure.
+// The priority is set to the context and the server metadata, so it's propagated to the downstream calls
+// by the metadata client middleware.
+// e.g. ratelimit.Shedder(ratelimit.WithPriority(ratelimit.PriorityByOperation(map[string]ratelimit.Priority{
+// "/grpc.health.v1.Health/*": ratelimit.PriorityCritical}), ratelimit.PriorityByMetadata()))
+func Shedder(opts ...ShedderOption) middleware.Middleware {
+	s := newShedder(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			p := s.priority(ctx)
+			ctx = NewPriorityContext(ctx, p)
+			// count the request first so the concurrent ones never pass the threshold together
+			if inFlight := atomic.AddInt64(&s.inFlight, 1); s.shouldShed(p, inFlight-1) {
+				atomic.AddInt64(&s.inFlight, -1)
+				return nil, ErrLimitExceed
+			}
+			if middleware.IsStream(ctx) {
+				// the long-lived streams are only admitted
+				atomic.AddInt64(&s.inFlight, -1)
+				return handler(ctx, req)
+			}
+			start := s.now()
+			defer func() {
+				atomic.AddInt64(&s.inFlight, -1)
+				now := s.now()
+				s.probe.done(now, now.Sub(start))
+			}()
+			return handler(ctx, req)
+		}
+	}
+}
+
+func newShedder(opts []ShedderOption) *shedder {
+	s := &shedder{
+		priorities:      []PriorityFunc{PriorityByMetadata()},
+		defaultPriority: PriorityNormal,
+		cpuThreshold:    800, //nolint:gomnd
+		cpu:             cpuUsage,
+		now:             time.Now,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// priority returns the priority resolved by the first func determines it.
+func (s *shedder) priority(ctx context.Context) Priority {
+	for _, f := range s.priorities {
+		if p, ok := f(ctx); ok {
+			return p
+		}
+	}
+	return s.defaultPriority
+}
+
+// shouldShed reports whether the request of the priority is shed with the in-flight requests ahead of it.
+func (s *shedder) shouldShed(p Priority, inFlight int64) bool {
+	if p >= PriorityCritical {
+		return false
+	}
+	ratio, ok := sheddingRatios[p]
+	if !ok {
+		p, ratio = PriorityLow, sheddingRatios[PriorityLow]
+	}
+	if s.maxInFlight > 0 && float64(inFlight) >= ratio*float64(s.maxInFlight) {
+		return true
+	}
+	if s.cpuThreshold <= 0 {
+		return false
+	}
+	now := s.now()
+	if now.Sub(time.Unix(0, atomic.LoadInt64(&s.dropped[p]))) > probeCoolDown &&
+		float64(s.cpu()) < ratio*float64(s.cpuThreshold) {
+		return false
+	}
+	capacity, ok := s.probe.capacity(now)
+	if !ok || inFlight <= 1 || float64(inFlight) <= ratio*capacity {
+		return false
+	}
+	atomic.StoreInt64(&s.dropped[p], now.UnixNano())
+	return true
+}
+
+// probe is the rolling window of the passes and the response times of the requests.
+type probe struct {
+	mu      sync.Mutex
+	buckets [probeBuckets]probeBucket
+}
+
+type probeBucket struct {
+	index int64
+	pass  int64
+	rt    time.Duration
+}
+
+func bucketIndex(now time.Time) int64 {
+	return now.UnixNano() / int64(probeWindow/probeBuckets)
+}
+
+// done records the pass and the response time of a request.
+func (p *probe) done(now time.Time, rt time.Duration) {
+	index := bucketIndex(now)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b := &p.buckets[index%probeBuckets]
+	if b.index != index {
+		*b = probeBucket{index: index}
+	}
+	b.pass++
+	b.rt += rt
+}
+
+// capacity returns the in-flight requests can be served, the max passes of a bucket
+// multiplied by the min average response time in buckets, of the completed buckets.
+func (p *probe) capacity(now time.Time) (float64, bool) {
+	index := bucketIndex(now)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var (
+		maxPass int64
+		minRT   = time.Duration(math.MaxInt64)
+	)
+	for _, b := range p.buckets {
+		if b.index >= index || b.index <= index-probeBuckets || b.pass == 0 {
+			continue
+		}
+		if b.pass > maxPass {
+			maxPass = b.pass
+		}
+		if rt := b.rt / time.Duration(b.pass); rt < minRT {
+			minRT = rt
+		}
+	}
+	if maxPass == 0 {
+		return 0, false
+	}
+	return float64(maxPass) * float64(minRT) / float64(probeWindow/probeBuckets), true
+}
@@ -0,0 +1,237 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/middleware"
+	mmd "github.com/go-kratos/kratos/v2/middleware/metadata"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+func TestParsePriority(t *testing.T) {
+	for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh, PriorityCritical} {
+		if got, ok := ParsePriority(p.String()); !ok || got != p {
+			t.Errorf("expect %v, got %v", p, got)
+		}
+	}
+	if _, ok := ParsePriority("urgent"); ok {
+		t.Error("expect the unknown priority rejected")
+	}
+}
+
+func TestShedderPriority(t *testing.T) {
+	f := PriorityByOperation(map[string]Priority{
+		"/helloworld.*":                 PriorityHigh,
+		"/helloworld.Greeter/*":         PriorityLow,
+		"/helloworld.Greeter/SayHello2": PriorityCritical,
+	})
+	var got Priority
+	h := Shedder(WithPriority(f, PriorityByMetadata()), WithCPUUsage(func() int64 { return 0 }))(
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			got, _ = PriorityFromContext(ctx)
+			return nil, nil
+		})
+	ctx := transport.NewServerContext(context.Background(), &Transport{})
+	if _, _ = h(ctx, nil); got != PriorityLow {
+		t.Errorf("expect %v, got %v", PriorityLow, got)
+	}
+	ctx = metadata.NewServerContext(ctx, metadata.New(map[string][]string{PriorityKey: {"critical"}}))
+	if _, _ = h(ctx, nil); got != PriorityLow {
+		t.Errorf("expect the operation config preferred, got %v", got)
+	}
+	ctx = metadata.NewServerContext(context.Background(), metadata.New(map[string][]string{PriorityKey: {"critical"}}))
+	if _, _ = h(ctx, nil); got != PriorityHigh {
+		t.Errorf("expect the metadata priority capped at %v, got %v", PriorityHigh, got)
+	}
+	if _, _ = h(context.Background(), nil); got != PriorityNormal {
+		t.Errorf("expect %v, got %v", PriorityNormal, got)
+	}
+}
+
+func TestShedderInFlight(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	h := Shedder(WithPriority(PriorityFromContext), WithMaxInFlight(10), WithCPUThreshold(0))(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if p, _ := PriorityFromContext(ctx); p == PriorityCritical {
+			entered <- struct{}{}
+			<-release
+		}
+		return nil, nil
+	})
+	call := func(p Priority) error {
+		_, err := h(NewPriorityContext(context.Background(), p), nil)
+		return err
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = call(PriorityCritical)
+		}()
+		<-entered
+	}
+	if err := call(PriorityLow); !kerrors.Is(err, ErrLimitExceed) {
+		t.Errorf("expect the low priority shed, got %v", err)
+	}
+	if err := call(PriorityNormal); err != nil {
+		t.Errorf("expect the normal priority allowed, got %v", err)
+	}
+	close(release)
+	wg.Wait()
+	if err := call(PriorityLow); err != nil {
+		t.Errorf("expect the low priority allowed, got %v", err)
+	}
+}
+
+func TestShedderStream(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	h := Shedder(WithMaxInFlight(1), WithCPUThreshold(0))(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if middleware.IsStream(ctx) {
+			close(entered)
+			<-release
+		}
+		return nil, nil
+	})
+	done := make(chan error)
+	go func() {
+		_, err := h(middleware.NewStreamContext(context.Background(), middleware.StreamCallWhole), nil)
+		done <- err
+	}()
+	<-entered
+	if _, err := h(NewPriorityContext(context.Background(), PriorityLow), nil); err != nil {
+		t.Errorf("expect the stream not in flight, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShedderConcurrent(t *testing.T) {
+	entered, release := make(chan bool, 50), make(chan struct{})
+	var admitted, peak int64
+	h := Shedder(WithPriority(PriorityFromContext), WithMaxInFlight(10), WithCPUThreshold(0))(func(context.Context, interface{}) (interface{}, error) {
+		n := atomic.AddInt64(&admitted, 1)
+		for {
+			if p := atomic.LoadInt64(&peak); n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+				break
+			}
+		}
+		entered <- true
+		<-release
+		atomic.AddInt64(&admitted, -1)
+		return nil, nil
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := h(NewPriorityContext(context.Background(), PriorityHigh), nil); err != nil {
+				entered <- false
+			}
+		}()
+	}
+	// the requests are either admitted and blocked or shed
+	for i := 0; i < 50; i++ {
+		<-entered
+	}
+	close(release)
+	wg.Wait()
+	if peak == 0 || peak > 10 {
+		t.Errorf("expect at most %d in flight, got %d", 10, peak)
+	}
+}
+
+func withShedderNow(now func() time.Time) ShedderOption {
+	return func(s *shedder) {
+		s.now = now
+	}
+}
+
+func TestShedderCPU(t *testing.T) {
+	var cpu int64
+	clock := newFakeClock(time.Unix(3600, 0))
+	s := newShedder([]ShedderOption{
+		WithCPUUsage(func() int64 { return atomic.LoadInt64(&cpu) }),
+		withShedderNow(clock.Now),
+	})
+	// the requests are admitted under the CPU pressure until the capacity is known
+	atomic.StoreInt64(&cpu, 1000)
+	if s.shouldShed(PriorityLow, 100) {
+		t.Error("expect the request probed without the capacity")
+	}
+	// 20 passes of 50ms per 100ms bucket serve 10 requests in flight
+	for i := 0; i < probeBuckets; i++ {
+		for j := 0; j < 20; j++ {
+			s.probe.done(clock.Now(), 50*time.Millisecond)
+		}
+		clock.Add(probeWindow / probeBuckets)
+	}
+	tests := []struct {
+		cpu      int64
+		inFlight int64
+		shed     []Priority
+	}{
+		{cpu: 600, inFlight: 100},
+		{cpu: 700, inFlight: 8},
+		{cpu: 700, inFlight: 11, shed: []Priority{PriorityLow}},
+		{cpu: 1000, inFlight: 1},
+		{cpu: 1000, inFlight: 8},
+		{cpu: 1000, inFlight: 9, shed: []Priority{PriorityLow}},
+		{cpu: 1000, inFlight: 10, shed: []Priority{PriorityLow, PriorityNormal}},
+		{cpu: 1000, inFlight: 11, shed: []Priority{PriorityLow, PriorityNormal, PriorityHigh}},
+	}
+	for _, test := range tests {
+		atomic.StoreInt64(&cpu, test.cpu)
+		shed := make(map[Priority]bool)
+		for _, p := range test.shed {
+			shed[p] = true
+		}
+		for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh, PriorityCritical} {
+			if got := s.shouldShed(p, test.inFlight); got != shed[p] {
+				t.Errorf("cpu %d in flight %d: expect %v shed %v, got %v", test.cpu, test.inFlight, p, shed[p], got)
+			}
+		}
+		// the probing of a test doesn't last to the next one
+		s.dropped = [PriorityCritical]int64{}
+	}
+
+	// the requests are still probed for a while after the pressure
+	atomic.StoreInt64(&cpu, 1000)
+	if !s.shouldShed(PriorityLow, 9) {
+		t.Fatal("expect the low priority shed")
+	}
+	atomic.StoreInt64(&cpu, 0)
+	if !s.shouldShed(PriorityLow, 9) {
+		t.Error("expect the low priority shed in the cool down")
+	}
+	clock.Add(probeCoolDown + time.Millisecond)
+	if s.shouldShed(PriorityLow, 100) {
+		t.Error("expect the low priority allowed after the cool down")
+	}
+}
+
+func TestShedderPropagation(t *testing.T) {
+	h := Shedder(WithPriority(PriorityByOperation(map[string]Priority{"/helloworld.Greeter/SayHello": PriorityHigh})),
+		WithCPUUsage(func() int64 { return 0 }))(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return mmd.Client()(func(context.Context, interface{}) (interface{}, error) { return nil, nil })(ctx, req)
+		})
+	client := &Transport{reqHeader: headerCarrier{}}
+	ctx := transport.NewServerContext(context.Background(), &Transport{})
+	ctx = transport.NewClientContext(ctx, client)
+	if _, err := h(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v := client.reqHeader.Get(PriorityKey); v != "high" {
+		t.Errorf("expect the priority %v propagated, got %v", "high", v)
+	}
+}
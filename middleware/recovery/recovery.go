@@ -2,14 +2,21 @@ package recovery
 
 import (
 	"context"
+	"fmt"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/errors"
+	ic "github.com/go-kratos/kratos/v2/internal/context"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
 )
 
+// defaultStackDepth is the default max number of the captured stack frames.
+const defaultStackDepth = 32
+
 // Latency is recovery latency context key
 type Latency struct{}
 
@@ -23,7 +30,9 @@ type HandlerFunc func(ctx context.Context, req, err interface{}) error
 type Option func(*options)
 
 type options struct {
-	handler HandlerFunc
+	handler    HandlerFunc
+	reporters  []Reporter
+	stackDepth int
 }
 
 // WithHandler with recovery handler.
@@ -33,26 +42,47 @@ func WithHandler(h HandlerFunc) Option {
 	}
 }
 
+// WithReporter with the reporters of the recovered panics,
+// default is LogReporter of the global logger.
+func WithReporter(reporters ...Reporter) Option {
+	return func(o *options) {
+		o.reporters = reporters
+	}
+}
+
+// WithStackDepth with the max number of the captured stack frames, default is 32,
+// zero value disables the stack capture.
+func WithStackDepth(depth int) Option {
+	return func(o *options) {
+		o.stackDepth = depth
+	}
+}
+
+type optionsKey struct{}
+
 // Recovery is a server middleware that recovers from any panics.
 func Recovery(opts ...Option) middleware.Middleware {
-	op := options{
+	op := &options{
 		handler: func(ctx context.Context, req, err interface{}) error {
 			return ErrUnknownRequest
 		},
+		reporters:  []Reporter{LogReporter(nil)},
+		stackDepth: defaultStackDepth,
 	}
 	for _, o := range opts {
-		o(&op)
+		o(op)
 	}
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
 			startTime := time.Now()
+			ctx = context.WithValue(ctx, optionsKey{}, op)
 			defer func() {
 				if rerr := recover(); rerr != nil {
-					buf := make([]byte, 64<<10) //nolint:gomnd
-					n := runtime.Stack(buf, false)
-					buf = buf[:n]
-					log.Context(ctx).Errorf("%v: %+v\n%s\n", rerr, req, buf)
-					ctx = context.WithValue(ctx, Latency{}, time.Since(startTime).Seconds())
+					p := newPanic(ctx, rerr, op.stackDepth)
+					p.Request = req
+					p.Latency = time.Since(startTime)
+					op.report(ctx, p)
+					ctx = context.WithValue(ctx, Latency{}, p.Latency.Seconds())
 					err = op.handler(ctx, req, rerr)
 				}
 			}()
@@ -60,3 +90,166 @@ func Recovery(opts ...Option) middleware.Middleware {
 		}
 	}
 }
+
+// Go runs fn in a new goroutine, the panics of fn are recovered and reported
+// to the reporters of the recovery middleware in ctx, or the global logger if none.
+// fn runs with a context keeps the values of ctx but isn't canceled with it,
+// as the request context is canceled when the handler returns.
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = ic.Detach(ctx)
+	op, ok := ctx.Value(optionsKey{}).(*options)
+	if !ok {
+		op = &options{reporters: []Reporter{LogReporter(nil)}, stackDepth: defaultStackDepth}
+	}
+	go func() {
+		startTime := time.Now()
+		defer func() {
+			if rerr := recover(); rerr != nil {
+				p := newPanic(ctx, rerr, op.stackDepth)
+				p.Goroutine = true
+				p.Latency = time.Since(startTime)
+				op.report(ctx, p)
+			}
+		}()
+		fn(ctx)
+	}()
+}
+
+func (o *options) report(ctx context.Context, p *Panic) {
+	for _, r := range o.reporters {
+		r.Report(ctx, p)
+	}
+}
+
+// Panic is the recovered panic.
+type Panic struct {
+	// Value is the recovered value.
+	Value interface{}
+	// Kind is the transport kind of the request, if any.
+	Kind string
+	// Operation is the operation of the request, if any.
+	Operation string
+	// Request is the request message, nil if recovered in a goroutine.
+	Request interface{}
+	// Stack is the stack frames of the panicking goroutine, from the panic site.
+	Stack []Frame
+	// Latency is the elapsed time since the request or the goroutine started.
+	Latency time.Duration
+	// Goroutine reports whether the panic is recovered in a goroutine spawned by Go.
+	Goroutine bool
+}
+
+// Frame is a stack frame.
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// String returns the stack frame in the runtime.Stack format.
+func (f Frame) String() string {
+	return fmt.Sprintf("%s\n\t%s:%d", f.Function, f.File, f.Line)
+}
+
+// Type returns the type of the panic value.
+func (p *Panic) Type() string {
+	return fmt.Sprintf("%T", p.Value)
+}
+
+// Err returns the panic value as an error.
+func (p *Panic) Err() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", p.Value)
+}
+
+// StackTrace returns the stack frames in the runtime.Stack format.
+func (p *Panic) StackTrace() string {
+	var b strings.Builder
+	for i, f := range p.Stack {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
+
+func newPanic(ctx context.Context, value interface{}, depth int) *Panic {
+	p := &Panic{Value: value}
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		p.Kind = tr.Kind().String()
+		p.Operation = tr.Operation()
+	}
+	if depth > 0 {
+		p.Stack = callers(depth)
+	}
+	return p
+}
+
+// callers returns the stack frames from the panic site, the frames of the recovery
+// and the runtime panic handling are trimmed.
+func callers(depth int) []Frame {
+	pcs := make([]uintptr, depth+16) //nolint:gomnd
+	n := runtime.Callers(3, pcs)     //nolint:gomnd
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make([]Frame, 0, depth)
+	panicking := false
+	for len(stack) < depth {
+		f, more := frames.Next()
+		switch {
+		case !panicking:
+			panicking = f.Function == "runtime.gopanic"
+		case len(stack) == 0 && isRuntimePanic(f.Function):
+			// the runtime error raisers, e.g. runtime.panicmem of nil dereferences
+		default:
+			stack = append(stack, Frame{Function: f.Function, File: f.File, Line: f.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+func isRuntimePanic(function string) bool {
+	return strings.HasPrefix(function, "runtime.panic") ||
+		strings.HasPrefix(function, "runtime.goPanic") ||
+		function == "runtime.sigpanic"
+}
+
+// LogReporter returns a reporter logs the panics with the structured fields,
+// the global logger is used if logger is nil.
+func LogReporter(logger log.Logger) Reporter {
+	return ReporterFunc(func(ctx context.Context, p *Panic) {
+		var h *log.Helper
+		if logger == nil {
+			h = log.Context(ctx)
+		} else {
+			h = log.NewHelper(log.WithContext(ctx, logger))
+		}
+		h.Log(log.LevelError,
+			"kind", p.Kind,
+			"operation", p.Operation,
+			"panic", fmt.Sprint(p.Value),
+			"panic_type", p.Type(),
+			"request", fmt.Sprintf("%+v", p.Request),
+			"goroutine", p.Goroutine,
+			"stack", p.StackTrace(),
+		)
+	})
+}
+
+// Reporter reports the recovered panics, e.g. to the logs, the traces or an error tracker.
+type Reporter interface {
+	Report(ctx context.Context, p *Panic)
+}
+
+// ReporterFunc is a function reports the recovered panics.
+type ReporterFunc func(ctx context.Context, p *Panic)
+
+// Report calls f(ctx, p).
+func (f ReporterFunc) Report(ctx context.Context, p *Panic) {
+	f(ctx, p)
+}
@@ -3,7 +3,9 @@ package recovery
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/errors"
 )
@@ -40,3 +42,70 @@ func TestNotPanic(t *testing.T) {
 		t.Errorf("e isn't nil")
 	}
 }
+
+func TestReporter(t *testing.T) {
+	var got *Panic
+	reporter := ReporterFunc(func(_ context.Context, p *Panic) { got = p })
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		var p *Panic
+		return p.Value, nil
+	}
+	_, err := Recovery(WithReporter(reporter), WithStackDepth(2))(next)(context.Background(), "req")
+	if !errors.Is(err, ErrUnknownRequest) {
+		t.Errorf("expect %v, got %v", ErrUnknownRequest, err)
+	}
+	if got == nil {
+		t.Fatal("expect the panic reported")
+	}
+	if got.Request != "req" || got.Type() != "runtime.errorString" {
+		t.Errorf("unexpected panic %+v", got)
+	}
+	if len(got.Stack) != 2 || !strings.HasSuffix(got.Stack[0].Function, "TestReporter.func2") {
+		t.Errorf("expect the stack from the panic site, got %v", got.StackTrace())
+	}
+}
+
+func TestGo(t *testing.T) {
+	reported := make(chan *Panic, 1)
+	reporter := ReporterFunc(func(_ context.Context, p *Panic) { reported <- p })
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		Go(ctx, func(context.Context) {
+			panic("goroutine panic")
+		})
+		return req, nil
+	}
+	if _, err := Recovery(WithReporter(reporter))(next)(context.Background(), "req"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case p := <-reported:
+		if !p.Goroutine || p.Value != "goroutine panic" || p.Type() != "string" {
+			t.Errorf("unexpected panic %+v", p)
+		}
+		if !strings.HasSuffix(p.Stack[0].Function, "TestGo.func2.1") {
+			t.Errorf("expect the stack from the panic site, got %v", p.StackTrace())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expect the goroutine panic reported")
+	}
+}
+
+func TestGoDetached(t *testing.T) {
+	type key struct{}
+	done := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	started := make(chan struct{})
+	Go(ctx, func(ctx context.Context) {
+		<-started
+		if ctx.Value(key{}) != "value" {
+			done <- fmt.Errorf("expect the values kept")
+			return
+		}
+		done <- ctx.Err()
+	})
+	cancel()
+	close(started)
+	if err := <-done; err != nil {
+		t.Errorf("expect the context not canceled, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/redact"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// The message events following the OpenTelemetry RPC semantic conventions.
+const (
+	messageEvent               = "message"
+	messageTypeKey             = attribute.Key("message.type")
+	messageIDKey               = attribute.Key("message.id")
+	messageUncompressedSizeKey = attribute.Key("message.uncompressed_size")
+	messagePayloadKey          = attribute.Key("message.payload")
+	messageTypeSent            = "SENT"
+	messageTypeReceived        = "RECEIVED"
+)
+
+const (
+	defaultPayloadMaxSize = 4096
+	truncatedSuffix       = "...(truncated)"
+
+	grpcRequestMetadataPrefix = "rpc.grpc.request.metadata."
+	httpRequestHeaderPrefix   = "http.request.header."
+)
+
+// AttributesFunc returns the span attributes of the request or the reply message.
+type AttributesFunc func(ctx context.Context, msg interface{}) []attribute.KeyValue
+
+// requestAttributes returns the configured attributes of the request.
+func (o *options) requestAttributes(ctx context.Context, tr transport.Transporter, req interface{}) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, key := range o.headers {
+		values := tr.RequestHeader().Values(key)
+		if len(values) == 0 {
+			continue
+		}
+		prefix := grpcRequestMetadataPrefix
+		if tr.Kind() == transport.KindHTTP {
+			prefix = httpRequestHeaderPrefix
+		}
+		name := strings.ReplaceAll(strings.ToLower(key), "-", "_")
+		attrs = append(attrs, attribute.StringSlice(prefix+name, values))
+	}
+	if o.requestAttrs != nil {
+		attrs = append(attrs, o.requestAttrs(ctx, req)...)
+	}
+	return attrs
+}
+
+// statusAttributes returns the status code attributes of the transport kind.
+func statusAttributes(kind transport.Kind, err error) []attribute.KeyValue {
+	se := errors.FromError(err)
+	switch kind {
+	case transport.KindHTTP:
+		code := 200
+		if se != nil {
+			code = int(se.Code)
+		}
+		return []attribute.KeyValue{semconv.HTTPStatusCodeKey.Int(code)}
+	case transport.KindGRPC:
+		code := 0
+		if se != nil {
+			code = int(se.GRPCStatus().Code())
+		}
+		return []attribute.KeyValue{semconv.RPCGRPCStatusCodeKey.Int(code)}
+	}
+	return nil
+}
+
+// addMessageEvent records the message as a span event if payloads are enabled.
+func (o *options) addMessageEvent(span trace.Span, messageType string, id int, msg interface{}) {
+	if !o.payloads || msg == nil {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		messageTypeKey.String(messageType),
+		messageIDKey.Int(id),
+	}
+	if p, ok := msg.(proto.Message); ok {
+		attrs = append(attrs, messageUncompressedSizeKey.Int(proto.Size(p)))
+	}
+	attrs = append(attrs, messagePayloadKey.String(o.payload(msg)))
+	span.AddEvent(messageEvent, trace.WithAttributes(attrs...))
+}
+
+// payload returns the redacted and truncated JSON of the message.
+func (o *options) payload(msg interface{}) string {
+	var (
+		b   []byte
+		err error
+	)
+	if m, ok := msg.(proto.Message); ok {
+		b, err = protojson.Marshal(o.redactor.Redact(m))
+	} else {
+		b, err = json.Marshal(msg)
+	}
+	if err != nil {
+		return err.Error()
+	}
+	if o.payloadMaxSize > 0 {
+		return redact.Truncate(string(b), o.payloadMaxSize, truncatedSuffix)
+	}
+	return string(b)
+}
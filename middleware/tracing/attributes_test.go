@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/redact"
+	"github.com/go-kratos/kratos/v2/internal/testdata/complex"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+func attrValue(attrs []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestSpanEnrichment(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+
+	header := headerCarrier{}
+	header.Set("X-Tenant-Id", "acme")
+	header.Set("User-Agent", "kratos-test")
+	tr := &mockTransport{kind: transport.KindHTTP, operation: "/test.Service/Get", header: header}
+	ctx := transport.NewServerContext(context.Background(), tr)
+
+	m := Server(
+		WithTracerProvider(tp),
+		WithHeaderAttributes("X-Tenant-Id", "X-Missing"),
+		WithRequestAttributes(func(_ context.Context, req interface{}) []attribute.KeyValue {
+			return []attribute.KeyValue{attribute.Int64("app.id", req.(*complex.Complex).Id)}
+		}),
+		WithReplyAttributes(func(_ context.Context, reply interface{}) []attribute.KeyValue {
+			return []attribute.KeyValue{attribute.String("app.reply", reply.(*complex.Simple).Component)}
+		}),
+		WithPayloads(),
+		WithPayloadMaxSize(64),
+		WithRedactFields("no_one"),
+	)
+	_, err := m(func(context.Context, interface{}) (interface{}, error) {
+		return &complex.Simple{Component: strings.Repeat("x", 100)}, nil
+	})(ctx, &complex.Complex{Id: 1, NoOne: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expect %v span, got %v", 1, len(spans))
+	}
+	attrs := spans[0].Attributes()
+	if v, _ := attrValue(attrs, "http.request.header.x_tenant_id"); len(v.AsStringSlice()) != 1 || v.AsStringSlice()[0] != "acme" {
+		t.Errorf("unexpected header attribute %v", v.Emit())
+	}
+	if _, ok := attrValue(attrs, "http.request.header.x_missing"); ok {
+		t.Error("expect the missing header skipped")
+	}
+	if v, _ := attrValue(attrs, "app.id"); v.AsInt64() != 1 {
+		t.Errorf("expect %v, got %v", 1, v.Emit())
+	}
+	if v, _ := attrValue(attrs, "app.reply"); !strings.HasPrefix(v.AsString(), "xxx") {
+		t.Errorf("unexpected reply attribute %v", v.Emit())
+	}
+	if v, _ := attrValue(attrs, "http.status_code"); v.AsInt64() != 200 {
+		t.Errorf("expect %v, got %v", 200, v.Emit())
+	}
+
+	events := spans[0].Events()
+	if len(events) != 2 {
+		t.Fatalf("expect %v events, got %v", 2, len(events))
+	}
+	if v, _ := attrValue(events[0].Attributes, messageTypeKey); v.AsString() != messageTypeReceived {
+		t.Errorf("expect %v, got %v", messageTypeReceived, v.Emit())
+	}
+	payload, _ := attrValue(events[0].Attributes, messagePayloadKey)
+	if strings.Contains(payload.AsString(), "secret") || !strings.Contains(payload.AsString(), `"id":"1"`) {
+		t.Errorf("unexpected request payload %v", payload.Emit())
+	}
+	if v, _ := attrValue(events[1].Attributes, messageTypeKey); v.AsString() != messageTypeSent {
+		t.Errorf("expect %v, got %v", messageTypeSent, v.Emit())
+	}
+	payload, _ = attrValue(events[1].Attributes, messagePayloadKey)
+	if len(payload.AsString()) != 64+len(truncatedSuffix) {
+		t.Errorf("expect the payload truncated, got %v", payload.Emit())
+	}
+}
+
+func TestStatusAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+
+	tr := &mockTransport{kind: transport.KindGRPC, operation: "/test.Service/Get", header: headerCarrier{}}
+	ctx := transport.NewClientContext(context.Background(), tr)
+	_, _ = Client(WithTracerProvider(tp), WithPayloads())(func(context.Context, interface{}) (interface{}, error) {
+		return nil, errors.NotFound("NOT_FOUND", "not found")
+	})(ctx, &complex.Complex{Id: 1})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expect %v span, got %v", 1, len(spans))
+	}
+	if v, _ := attrValue(spans[0].Attributes(), "rpc.grpc.status_code"); v.AsInt64() != 5 {
+		t.Errorf("expect %v, got %v", 5, v.Emit())
+	}
+	// only the sent request is recorded on failure
+	var messages int
+	for _, event := range spans[0].Events() {
+		if event.Name == messageEvent {
+			messages++
+		}
+	}
+	if messages != 1 {
+		t.Errorf("expect %v message event, got %v", 1, messages)
+	}
+}
+
+func TestPayloadTruncate(t *testing.T) {
+	o := &options{payloadMaxSize: 4, redactor: redact.New()}
+	// the multi-byte runes aren't split
+	if got := o.payload("ab你好"); got != `"ab`+truncatedSuffix {
+		t.Errorf("expect %s, got %s", `"ab`+truncatedSuffix, got)
+	}
+	if got := o.payload("ab"); got != `"ab"` {
+		t.Errorf("expect %s, got %s", `"ab"`, got)
+	}
+}
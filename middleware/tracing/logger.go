@@ -0,0 +1,127 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	logEvent       = "log"
+	logSeverityKey = attribute.Key("log.severity")
+
+	defaultTraceIDKey = "trace_id"
+	defaultSpanIDKey  = "span_id"
+)
+
+// LoggerOption is trace-aware logger option.
+type LoggerOption func(*Logger)
+
+// WithTraceKeys with the keys of the injected trace id and span id,
+// default is trace_id and span_id.
+func WithTraceKeys(traceIDKey, spanIDKey string) LoggerOption {
+	return func(l *Logger) {
+		l.traceIDKey = traceIDKey
+		l.spanIDKey = spanIDKey
+	}
+}
+
+// WithSpanEvents with the logs at or above the level recorded as events of the recording span,
+// e.g. log.LevelError.
+func WithSpanEvents(level log.Level) LoggerOption {
+	return func(l *Logger) {
+		l.events = true
+		l.eventLevel = level
+	}
+}
+
+// WithLevel with the minimum level of the logs, default is log.LevelDebug.
+func WithLevel(level log.Level) LoggerOption {
+	return func(l *Logger) {
+		l.level = level
+	}
+}
+
+// WithSampledLevel with the minimum level of the logs of the sampled traces,
+// to raise the verbosity of the sampled requests, e.g. log.LevelDebug.
+func WithSampledLevel(level log.Level) LoggerOption {
+	return func(l *Logger) {
+		l.sampledLevel = level
+		l.hasSampledLevel = true
+	}
+}
+
+// Logger is a logger injects the trace context of the ctx passed via log.WithContext.
+type Logger struct {
+	logger          log.Logger
+	ctx             context.Context
+	traceIDKey      string
+	spanIDKey       string
+	events          bool
+	eventLevel      log.Level
+	level           log.Level
+	sampledLevel    log.Level
+	hasSampledLevel bool
+}
+
+var _ log.ContextLogger = (*Logger)(nil)
+
+// NewLogger returns a trace-aware logger wraps the logger.
+func NewLogger(logger log.Logger, opts ...LoggerOption) *Logger {
+	l := &Logger{
+		logger:     logger,
+		ctx:        context.Background(),
+		traceIDKey: defaultTraceIDKey,
+		spanIDKey:  defaultSpanIDKey,
+		level:      log.LevelDebug,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// WithContext returns a shallow copy of the logger with its context changed to ctx.
+func (l *Logger) WithContext(ctx context.Context) log.Logger {
+	lv := *l
+	lv.ctx = ctx
+	return &lv
+}
+
+// Log injects the trace context and prints the keyvals.
+func (l *Logger) Log(level log.Level, keyvals ...interface{}) error {
+	sc := trace.SpanContextFromContext(l.ctx)
+	minLevel := l.level
+	if l.hasSampledLevel && sc.IsSampled() {
+		minLevel = l.sampledLevel
+	}
+	if level < minLevel {
+		return nil
+	}
+	if !sc.IsValid() {
+		return l.logger.Log(level, keyvals...)
+	}
+	if l.events && level >= l.eventLevel {
+		if span := trace.SpanFromContext(l.ctx); span.IsRecording() {
+			span.AddEvent(logEvent, trace.WithAttributes(logAttributes(level, keyvals)...))
+		}
+	}
+	kvs := make([]interface{}, 0, len(keyvals)+4)
+	kvs = append(kvs, l.traceIDKey, sc.TraceID().String(), l.spanIDKey, sc.SpanID().String())
+	kvs = append(kvs, keyvals...)
+	return l.logger.Log(level, kvs...)
+}
+
+// logAttributes returns the span event attributes of the log keyvals.
+func logAttributes(level log.Level, keyvals []interface{}) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(keyvals)/2+1)
+	attrs = append(attrs, logSeverityKey.String(level.String()))
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		attrs = append(attrs, attribute.String(fmt.Sprint(keyvals[i]), fmt.Sprint(keyvals[i+1])))
+	}
+	return attrs
+}
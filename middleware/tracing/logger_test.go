@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+func TestLogger(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "test")
+
+	buf := new(bytes.Buffer)
+	logger := log.With(NewLogger(log.NewStdLogger(buf),
+		WithLevel(log.LevelWarn),
+		WithSampledLevel(log.LevelDebug),
+		WithSpanEvents(log.LevelError),
+	), "service", "test")
+
+	h := log.NewHelper(log.WithContext(ctx, logger))
+	h.Debug("debug")
+	h.Errorw("msg", "failed", "code", 500)
+	span.End()
+
+	out := buf.String()
+	sc := span.SpanContext()
+	if !strings.Contains(out, "DEBUG trace_id="+sc.TraceID().String()+" span_id="+sc.SpanID().String()+" service=test msg=debug") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if !strings.Contains(out, "ERROR trace_id="+sc.TraceID().String()) {
+		t.Errorf("unexpected output %q", out)
+	}
+	events := recorder.Ended()[0].Events()
+	if len(events) != 1 || events[0].Name != logEvent {
+		t.Fatalf("expect %v log event, got %v", 1, events)
+	}
+	if v, _ := attrValue(events[0].Attributes, logSeverityKey); v.AsString() != "ERROR" {
+		t.Errorf("expect %v, got %v", "ERROR", v.Emit())
+	}
+	if v, _ := attrValue(events[0].Attributes, "msg"); v.AsString() != "failed" {
+		t.Errorf("expect %v, got %v", "failed", v.Emit())
+	}
+
+	// the unsampled requests are logged at the base level
+	buf.Reset()
+	tp = tracesdk.NewTracerProvider(tracesdk.WithSampler(tracesdk.NeverSample()))
+	ctx, span = tp.Tracer("test").Start(context.Background(), "test")
+	defer span.End()
+	h = log.NewHelper(log.WithContext(ctx, logger))
+	h.Info("info")
+	h.Warn("warn")
+	if out = buf.String(); strings.Contains(out, "info") || !strings.Contains(out, "WARN trace_id=") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	// no trace context
+	buf.Reset()
+	h = log.NewHelper(logger)
+	h.Warn("warn")
+	if out = buf.String(); strings.Contains(out, "trace_id") || !strings.Contains(out, "msg=warn") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
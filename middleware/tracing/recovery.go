@@ -0,0 +1,25 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+)
+
+// PanicReporter returns a recovery reporter records the panics as the exception events
+// of the span in ctx, so the recovery middleware must be placed after the tracing middleware.
+func PanicReporter() recovery.Reporter {
+	return recovery.ReporterFunc(func(ctx context.Context, p *recovery.Panic) {
+		span := trace.SpanFromContext(ctx)
+		if !span.IsRecording() {
+			return
+		}
+		err := p.Err()
+		span.RecordError(err, trace.WithAttributes(semconv.ExceptionStacktraceKey.String(p.StackTrace())))
+		span.SetStatus(codes.Error, err.Error())
+	})
+}
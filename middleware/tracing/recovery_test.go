@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+func TestPanicReporter(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+
+	tr := &mockTransport{kind: transport.KindGRPC, operation: "/test.Service/Get", header: headerCarrier{}}
+	ctx := transport.NewServerContext(context.Background(), tr)
+	h := Server(WithTracerProvider(tp))(recovery.Recovery(recovery.WithReporter(PanicReporter()))(
+		func(context.Context, interface{}) (interface{}, error) {
+			panic("boom")
+		}))
+	if _, err := h(ctx, nil); err == nil {
+		t.Fatal("expect the recovered error")
+	}
+
+	span := recorder.Ended()[0]
+	if span.Status().Code != codes.Error {
+		t.Errorf("expect %v, got %v", codes.Error, span.Status().Code)
+	}
+	for _, event := range span.Events() {
+		if event.Name != semconv.ExceptionEventName {
+			continue
+		}
+		if v, _ := attrValue(event.Attributes, semconv.ExceptionMessageKey); v.AsString() != "boom" {
+			t.Errorf("expect %v, got %v", "boom", v.Emit())
+		}
+		if v, _ := attrValue(event.Attributes, semconv.ExceptionStacktraceKey); !strings.Contains(v.AsString(), "TestPanicReporter") {
+			t.Errorf("unexpected stack trace %v", v.Emit())
+		}
+		return
+	}
+	t.Error("expect the exception event")
+}
@@ -76,6 +76,9 @@ func setServerSpan(ctx context.Context, span trace.Span, m interface{}) {
 				attrs = append(attrs, semconv.HTTPMethodKey.String(method))
 				attrs = append(attrs, semconv.HTTPRouteKey.String(route))
 				attrs = append(attrs, semconv.HTTPTargetKey.String(path))
+				if ua := ht.Request().UserAgent(); ua != "" {
+					attrs = append(attrs, semconv.HTTPUserAgentKey.String(ua))
+				}
 				remote = ht.Request().RemoteAddr
 			}
 		case transport.KindGRPC:
@@ -12,6 +12,8 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/internal/redact"
+	"github.com/go-kratos/kratos/v2/transport"
 )
 
 // Tracer is otel span tracer
@@ -24,8 +26,10 @@ type Tracer struct {
 // NewTracer create tracer instance
 func NewTracer(kind trace.SpanKind, opts ...Option) *Tracer {
 	op := options{
-		propagator: propagation.NewCompositeTextMapPropagator(Metadata{}, propagation.Baggage{}, propagation.TraceContext{}),
-		tracerName: "kratos",
+		propagator:     propagation.NewCompositeTextMapPropagator(Metadata{}, propagation.Baggage{}, propagation.TraceContext{}),
+		tracerName:     "kratos",
+		payloadMaxSize: defaultPayloadMaxSize,
+		redactor:       redact.New(),
 	}
 	for _, o := range opts {
 		o(&op)
@@ -59,8 +63,39 @@ func (t *Tracer) Start(ctx context.Context, operation string, carrier propagatio
 	return ctx, span
 }
 
+// setRequest sets the configured attributes and the payload event of the request.
+func (t *Tracer) setRequest(ctx context.Context, span trace.Span, tr transport.Transporter, req interface{}) {
+	span.SetAttributes(t.opt.requestAttributes(ctx, tr, req)...)
+	messageType := messageTypeReceived
+	if t.kind == trace.SpanKindClient {
+		messageType = messageTypeSent
+	}
+	t.opt.addMessageEvent(span, messageType, 1, req)
+}
+
+// transporter returns the transport of the span kind in ctx.
+func (t *Tracer) transporter(ctx context.Context) (transport.Transporter, bool) {
+	if t.kind == trace.SpanKindServer {
+		return transport.FromServerContext(ctx)
+	}
+	return transport.FromClientContext(ctx)
+}
+
 // End finish tracing span
-func (t *Tracer) End(_ context.Context, span trace.Span, m interface{}, err error) {
+func (t *Tracer) End(ctx context.Context, span trace.Span, m interface{}, err error) {
+	if tr, ok := t.transporter(ctx); ok {
+		span.SetAttributes(statusAttributes(tr.Kind(), err)...)
+	}
+	if t.opt.replyAttrs != nil {
+		span.SetAttributes(t.opt.replyAttrs(ctx, m)...)
+	}
+	if err == nil {
+		messageType := messageTypeSent
+		if t.kind == trace.SpanKindClient {
+			messageType = messageTypeReceived
+		}
+		t.opt.addMessageEvent(span, messageType, 1, m)
+	}
 	if err != nil {
 		span.RecordError(err)
 		if e := errors.FromError(err); e != nil {
@@ -8,6 +8,7 @@ import (
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/go-kratos/kratos/v2/internal/redact"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
 )
@@ -19,6 +20,12 @@ type options struct {
 	tracerName     string
 	tracerProvider trace.TracerProvider
 	propagator     propagation.TextMapPropagator
+	headers        []string
+	requestAttrs   AttributesFunc
+	replyAttrs     AttributesFunc
+	payloads       bool
+	payloadMaxSize int
+	redactor       *redact.Redactor
 }
 
 // WithPropagator with tracer propagator.
@@ -43,6 +50,52 @@ func WithTracerName(tracerName string) Option {
 	}
 }
 
+// WithHeaderAttributes with the request headers recorded as span attributes,
+// named http.request.header.<key> for HTTP and rpc.grpc.request.metadata.<key> for gRPC.
+func WithHeaderAttributes(keys ...string) Option {
+	return func(opts *options) {
+		opts.headers = append(opts.headers, keys...)
+	}
+}
+
+// WithRequestAttributes with the func returns the span attributes of the request.
+func WithRequestAttributes(f AttributesFunc) Option {
+	return func(opts *options) {
+		opts.requestAttrs = f
+	}
+}
+
+// WithReplyAttributes with the func returns the span attributes of the reply,
+// the reply is nil if the request failed.
+func WithReplyAttributes(f AttributesFunc) Option {
+	return func(opts *options) {
+		opts.replyAttrs = f
+	}
+}
+
+// WithPayloads with recording the request and reply payloads as message span events.
+func WithPayloads() Option {
+	return func(opts *options) {
+		opts.payloads = true
+	}
+}
+
+// WithPayloadMaxSize with the max size of the recorded payloads, default is 4096,
+// zero value means no limit.
+func WithPayloadMaxSize(size int) Option {
+	return func(opts *options) {
+		opts.payloadMaxSize = size
+	}
+}
+
+// WithRedactFields with the dot-separated proto field paths redacted from the payloads,
+// e.g. "user.password". Fields annotated with the debug_redact option are always redacted.
+func WithRedactFields(paths ...string) Option {
+	return func(opts *options) {
+		opts.redactor.AddPaths(paths...)
+	}
+}
+
 // Server returns a new server middleware for OpenTelemetry.
 func Server(opts ...Option) middleware.Middleware {
 	tracer := NewTracer(trace.SpanKindServer, opts...)
@@ -52,6 +105,7 @@ func Server(opts ...Option) middleware.Middleware {
 				var span trace.Span
 				ctx, span = tracer.Start(ctx, tr.Operation(), tr.RequestHeader())
 				setServerSpan(ctx, span, req)
+				tracer.setRequest(ctx, span, tr, req)
 				defer func() { tracer.End(ctx, span, reply, err) }()
 			}
 			return handler(ctx, req)
@@ -68,6 +122,7 @@ func Client(opts ...Option) middleware.Middleware {
 				var span trace.Span
 				ctx, span = tracer.Start(ctx, tr.Operation(), tr.RequestHeader())
 				setClientSpan(ctx, span, req)
+				tracer.setRequest(ctx, span, tr, req)
 				defer func() { tracer.End(ctx, span, reply, err) }()
 			}
 			return handler(ctx, req)
@@ -3,6 +3,8 @@ package validate
 import (
 	"context"
 
+	"google.golang.org/protobuf/proto"
+
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/middleware"
 )
@@ -11,16 +13,124 @@ type validator interface {
 	Validate() error
 }
 
+type allValidator interface {
+	ValidateAll() error
+}
+
+// multiError is the multiple errors returned by ValidateAll of protoc-gen-validate.
+type multiError interface {
+	AllErrors() []error
+}
+
+// fieldError is the field error generated by protoc-gen-validate.
+type fieldError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// ruleError is the field error which knows the violated rule.
+type ruleError interface {
+	Rule() string
+}
+
+// violationError is the error which reports its field violations itself.
+type violationError interface {
+	FieldViolations() []*errors.FieldViolation
+}
+
+// ProtoValidator validates any proto message by the constraints in its descriptor,
+// e.g. protovalidate.Validator.
+type ProtoValidator interface {
+	Validate(msg proto.Message) error
+}
+
+// Option is validator option.
+type Option func(*options)
+
+type options struct {
+	all   bool
+	proto ProtoValidator
+}
+
+// WithValidateAll with validating all the rules by ValidateAll instead of
+// returning the first violation, if the request supports it.
+func WithValidateAll() Option {
+	return func(o *options) {
+		o.all = true
+	}
+}
+
+// WithProtoValidator with the validator of proto messages, it takes precedence
+// over the generated Validate method, so the messages need no code generation.
+func WithProtoValidator(v ProtoValidator) Option {
+	return func(o *options) {
+		o.proto = v
+	}
+}
+
 // Validator is a validator middleware.
-func Validator() middleware.Middleware {
+func Validator(opts ...Option) middleware.Middleware {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
-			if v, ok := req.(validator); ok {
-				if err := v.Validate(); err != nil {
-					return nil, errors.BadRequest("VALIDATOR", err.Error()).WithCause(err)
-				}
+			if err := o.validate(req); err != nil {
+				return nil, err
 			}
 			return handler(ctx, req)
 		}
 	}
 }
+
+func (o *options) validate(req interface{}) error {
+	var err error
+	if m, ok := req.(proto.Message); ok && o.proto != nil {
+		err = o.proto.Validate(m)
+	} else if v, ok := req.(allValidator); ok && o.all {
+		err = v.ValidateAll()
+	} else if v, ok := req.(validator); ok {
+		err = v.Validate()
+	}
+	if err == nil {
+		return nil
+	}
+	return errors.BadRequest("VALIDATOR", err.Error()).
+		WithCause(err).
+		WithFieldViolations(Violations(err)...)
+}
+
+// Violations returns the field violations of a validation error.
+func Violations(err error) []*errors.FieldViolation {
+	if v, ok := err.(violationError); ok {
+		return v.FieldViolations()
+	}
+	return appendViolations(nil, "", err)
+}
+
+func appendViolations(violations []*errors.FieldViolation, prefix string, err error) []*errors.FieldViolation {
+	switch e := err.(type) {
+	case multiError:
+		for _, err := range e.AllErrors() {
+			violations = appendViolations(violations, prefix, err)
+		}
+	case fieldError:
+		field := e.Field()
+		if prefix != "" {
+			field = prefix + "." + field
+		}
+		if cause := e.Cause(); cause != nil {
+			if nested := appendViolations(nil, field, cause); len(nested) > 0 {
+				return append(violations, nested...)
+			}
+		}
+		violation := &errors.FieldViolation{Field: field, Message: e.Reason()}
+		if r, ok := err.(ruleError); ok {
+			violation.Rule = r.Rule()
+		}
+		violations = append(violations, violation)
+	}
+	return violations
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"testing"
 
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
 	kratoserrors "github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/middleware"
 )
@@ -41,3 +44,103 @@ func TestTable(t *testing.T) {
 		})
 	}
 }
+
+// fieldErr mocks the field error generated by protoc-gen-validate.
+type fieldErr struct {
+	field  string
+	reason string
+	cause  error
+}
+
+func (e fieldErr) Field() string  { return e.field }
+func (e fieldErr) Reason() string { return e.reason }
+func (e fieldErr) Cause() error   { return e.cause }
+func (e fieldErr) Error() string  { return "invalid " + e.field + ": " + e.reason }
+
+// multiErr mocks the multi error generated by protoc-gen-validate.
+type multiErr []error
+
+func (m multiErr) Error() string      { return "multiple errors" }
+func (m multiErr) AllErrors() []error { return m }
+
+type allVali struct{}
+
+func (allVali) Validate() error {
+	return fieldErr{field: "Name", reason: "value length must be at least 1 runes"}
+}
+
+func (allVali) ValidateAll() error {
+	return multiErr{
+		fieldErr{field: "Name", reason: "value length must be at least 1 runes"},
+		fieldErr{field: "User", reason: "embedded message failed validation", cause: multiErr{
+			fieldErr{field: "Age", reason: "value must be greater than 0"},
+		}},
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	var mock middleware.Handler = func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	_, err := Validator()(mock)(context.Background(), allVali{})
+	violations := kratoserrors.FieldViolations(err)
+	if len(violations) != 1 || violations[0].Field != "Name" {
+		t.Errorf("expect 1 violation of Name, got %v", violations)
+	}
+
+	_, err = Validator(WithValidateAll())(mock)(context.Background(), allVali{})
+	if !kratoserrors.IsBadRequest(err) {
+		t.Errorf("expect bad request, got %v", err)
+	}
+	want := []*kratoserrors.FieldViolation{
+		{Field: "Name", Message: "value length must be at least 1 runes"},
+		{Field: "User.Age", Message: "value must be greater than 0"},
+	}
+	violations = kratoserrors.FieldViolations(err)
+	if len(violations) != len(want) {
+		t.Fatalf("expect %v, got %v", want, violations)
+	}
+	for i := range want {
+		if !proto.Equal(violations[i], want[i]) {
+			t.Errorf("expect %v, got %v", want[i], violations[i])
+		}
+	}
+}
+
+func TestViolationsOfPlainError(t *testing.T) {
+	if violations := Violations(errors.New("err")); len(violations) != 0 {
+		t.Errorf("expect no violations, got %v", violations)
+	}
+}
+
+type protoValidatorMock struct{ err error }
+
+func (v protoValidatorMock) Validate(proto.Message) error { return v.err }
+
+type violationErr []*kratoserrors.FieldViolation
+
+func (e violationErr) Error() string                                   { return "validation error" }
+func (e violationErr) FieldViolations() []*kratoserrors.FieldViolation { return e }
+
+func TestProtoValidator(t *testing.T) {
+	var mock middleware.Handler = func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	want := &kratoserrors.FieldViolation{Field: "name", Rule: "string.min_len", Message: "value length must be at least 1 characters"}
+	v := Validator(WithProtoValidator(protoValidatorMock{err: violationErr{want}}))(mock)
+	_, err := v(context.Background(), &emptypb.Empty{})
+	if !kratoserrors.IsBadRequest(err) {
+		t.Fatalf("expect bad request, got %v", err)
+	}
+	if violations := kratoserrors.FieldViolations(err); len(violations) != 1 || !proto.Equal(violations[0], want) {
+		t.Errorf("expect %v, got %v", want, violations)
+	}
+
+	// non proto requests fall back to the generated method
+	if _, err = v(context.Background(), protoVali{"", 1, true}); !kratoserrors.IsBadRequest(err) {
+		t.Errorf("expect bad request, got %v", err)
+	}
+
+	v = Validator(WithProtoValidator(protoValidatorMock{}))(mock)
+	if _, err = v(context.Background(), &emptypb.Empty{}); err != nil {
+		t.Errorf("expect %v, got %v", nil, err)
+	}
+}
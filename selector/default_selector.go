@@ -62,15 +62,44 @@ func (d *Default) Select(ctx context.Context, opts ...SelectOption) (selected No
 }
 
 // Apply update nodes info.
+// Unchanged nodes are kept, so their runtime statistics and warm-up state survive.
 func (d *Default) Apply(nodes []Node) {
+	old, _ := d.nodes.Load().([]WeightedNode)
+	existing := make(map[string]WeightedNode, len(old))
+	for _, wn := range old {
+		existing[wn.Address()] = wn
+	}
 	weightedNodes := make([]WeightedNode, 0, len(nodes))
 	for _, n := range nodes {
+		if wn, ok := existing[n.Address()]; ok && sameNode(wn.Raw(), n) {
+			weightedNodes = append(weightedNodes, wn)
+			continue
+		}
 		weightedNodes = append(weightedNodes, d.NodeBuilder.Build(n))
 	}
-	// TODO: Do not delete unchanged nodes
 	d.nodes.Store(weightedNodes)
 }
 
+func sameNode(a, b Node) bool {
+	if a.Scheme() != b.Scheme() || a.ServiceName() != b.ServiceName() || a.Version() != b.Version() {
+		return false
+	}
+	wa, wb := a.InitialWeight(), b.InitialWeight()
+	if (wa == nil) != (wb == nil) || (wa != nil && *wa != *wb) {
+		return false
+	}
+	ma, mb := a.Metadata(), b.Metadata()
+	if len(ma) != len(mb) {
+		return false
+	}
+	for k, v := range ma {
+		if vb, ok := mb[k]; !ok || vb != v {
+			return false
+		}
+	}
+	return true
+}
+
 // DefaultBuilder is de
 type DefaultBuilder struct {
 	Node     WeightedNodeBuilder
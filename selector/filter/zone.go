@@ -0,0 +1,146 @@
+package filter
+
+import (
+	"context"
+	"os"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+const (
+	// ZoneKey is the metadata key of the availability zone.
+	ZoneKey = "zone"
+	// RegionKey is the metadata key of the region.
+	RegionKey = "region"
+
+	// ZoneEnv is the environment variable of the local zone.
+	ZoneEnv = "KRATOS_ZONE"
+	// RegionEnv is the environment variable of the local region.
+	RegionEnv = "KRATOS_REGION"
+)
+
+// ZoneOption is zone filter option.
+type ZoneOption func(*zoneOptions)
+
+type zoneOptions struct {
+	zone      string
+	region    string
+	zoneKey   string
+	regionKey string
+	minNodes  int
+	minRatio  float64
+}
+
+// WithZone with the local zone, it takes precedence over app metadata and environment.
+func WithZone(zone string) ZoneOption {
+	return func(o *zoneOptions) {
+		o.zone = zone
+	}
+}
+
+// WithRegion with the local region, it takes precedence over app metadata and environment.
+func WithRegion(region string) ZoneOption {
+	return func(o *zoneOptions) {
+		o.region = region
+	}
+}
+
+// WithZoneKey with the metadata key used to read the zone.
+func WithZoneKey(key string) ZoneOption {
+	return func(o *zoneOptions) {
+		o.zoneKey = key
+	}
+}
+
+// WithRegionKey with the metadata key used to read the region.
+func WithRegionKey(key string) ZoneOption {
+	return func(o *zoneOptions) {
+		o.regionKey = key
+	}
+}
+
+// WithMinNodes with the minimum number of local nodes,
+// below which traffic spills over to other zones.
+func WithMinNodes(n int) ZoneOption {
+	return func(o *zoneOptions) {
+		o.minNodes = n
+	}
+}
+
+// WithMinRatio with the minimum ratio of local nodes to all nodes,
+// below which traffic spills over to other zones.
+func WithMinRatio(ratio float64) ZoneOption {
+	return func(o *zoneOptions) {
+		o.minRatio = ratio
+	}
+}
+
+// Zone is locality aware filter, it prefers nodes in the local zone,
+// then nodes in the local region, and falls back to all nodes
+// when the preferred set is below the threshold.
+//
+// The local zone is resolved in order from the options, the metadata
+// of the kratos.AppInfo in context and the KRATOS_ZONE/KRATOS_REGION environment.
+func Zone(opts ...ZoneOption) selector.NodeFilter {
+	o := zoneOptions{
+		zoneKey:   ZoneKey,
+		regionKey: RegionKey,
+		minNodes:  1,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	envZone, envRegion := os.Getenv(ZoneEnv), os.Getenv(RegionEnv)
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		zone, region := o.zone, o.region
+		if info, ok := kratos.FromContext(ctx); ok {
+			md := info.Metadata()
+			if zone == "" {
+				zone = md[o.zoneKey]
+			}
+			if region == "" {
+				region = md[o.regionKey]
+			}
+		}
+		if zone == "" {
+			zone = envZone
+		}
+		if region == "" {
+			region = envRegion
+		}
+		if zone == "" && region == "" {
+			return nodes
+		}
+		if zone != "" {
+			local := o.match(nodes, o.zoneKey, zone)
+			if o.enough(len(local), len(nodes)) {
+				return local
+			}
+		}
+		if region != "" {
+			local := o.match(nodes, o.regionKey, region)
+			if o.enough(len(local), len(nodes)) {
+				return local
+			}
+		}
+		return nodes
+	}
+}
+
+func (o *zoneOptions) match(nodes []selector.Node, key, value string) []selector.Node {
+	newNodes := make([]selector.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.Metadata()[key] == value {
+			newNodes = append(newNodes, n)
+		}
+	}
+	return newNodes
+}
+
+func (o *zoneOptions) enough(local, total int) bool {
+	if local == 0 || local < o.minNodes {
+		return false
+	}
+	return float64(local) >= o.minRatio*float64(total)
+}
@@ -0,0 +1,73 @@
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+func newZoneNode(addr, region, zone string) selector.Node {
+	return selector.NewNode("http", addr, &registry.ServiceInstance{
+		ID:        addr,
+		Name:      "helloworld",
+		Endpoints: []string{"http://" + addr},
+		Metadata:  map[string]string{RegionKey: region, ZoneKey: zone},
+	})
+}
+
+func zoneNodes() []selector.Node {
+	return []selector.Node{
+		newZoneNode("127.0.0.1:9090", "cn-east", "az1"),
+		newZoneNode("127.0.0.2:9090", "cn-east", "az1"),
+		newZoneNode("127.0.0.3:9090", "cn-east", "az2"),
+		newZoneNode("127.0.0.4:9090", "cn-north", "az3"),
+	}
+}
+
+func TestZone(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ZoneOption
+		want int
+	}{
+		{"local zone", []ZoneOption{WithZone("az1"), WithRegion("cn-east")}, 2},
+		{"spill over to region", []ZoneOption{WithZone("az1"), WithRegion("cn-east"), WithMinNodes(3)}, 3},
+		{"spill over to all", []ZoneOption{WithZone("az3"), WithRegion("cn-north"), WithMinNodes(2)}, 4},
+		{"min ratio", []ZoneOption{WithZone("az1"), WithMinRatio(0.6)}, 4},
+		{"unknown zone", []ZoneOption{WithZone("az9")}, 4},
+		{"no locality", nil, 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodes := Zone(test.opts...)(context.Background(), zoneNodes())
+			if len(nodes) != test.want {
+				t.Errorf("expect %v, got %v", test.want, len(nodes))
+			}
+		})
+	}
+}
+
+func TestZoneFromApp(t *testing.T) {
+	app := kratos.New(kratos.Metadata(map[string]string{ZoneKey: "az2"}))
+	nodes := Zone()(kratos.NewContext(context.Background(), app), zoneNodes())
+	if len(nodes) != 1 {
+		t.Fatalf("expect %v, got %v", 1, len(nodes))
+	}
+	if nodes[0].Address() != "127.0.0.3:9090" {
+		t.Errorf("expect %v, got %v", "127.0.0.3:9090", nodes[0].Address())
+	}
+}
+
+func TestZoneFromEnv(t *testing.T) {
+	t.Setenv(ZoneEnv, "az3")
+	nodes := Zone()(context.Background(), zoneNodes())
+	if len(nodes) != 1 {
+		t.Fatalf("expect %v, got %v", 1, len(nodes))
+	}
+	if nodes[0].Address() != "127.0.0.4:9090" {
+		t.Errorf("expect %v, got %v", "127.0.0.4:9090", nodes[0].Address())
+	}
+}
@@ -23,14 +23,24 @@ type Node struct {
 
 	// last lastPick timestamp
 	lastPick int64
+
+	warmup *selector.Warmup
+	start  time.Time
 }
 
 // Builder is direct node builder
-type Builder struct{}
+type Builder struct {
+	// Warmup is the slow start config, nil means disabled.
+	Warmup *selector.Warmup
+}
 
 // Build create node
-func (*Builder) Build(n selector.Node) selector.WeightedNode {
-	return &Node{Node: n, lastPick: 0}
+func (b *Builder) Build(n selector.Node) selector.WeightedNode {
+	node := &Node{Node: n, lastPick: 0, warmup: b.Warmup}
+	if b.Warmup != nil {
+		node.start = b.Warmup.StartTime(n)
+	}
+	return node
 }
 
 func (n *Node) Pick() selector.DoneFunc {
@@ -41,10 +51,11 @@ func (n *Node) Pick() selector.DoneFunc {
 
 // Weight is node effective weight
 func (n *Node) Weight() float64 {
+	weight := float64(defaultWeight)
 	if n.InitialWeight() != nil {
-		return float64(*n.InitialWeight())
+		weight = float64(*n.InitialWeight())
 	}
-	return defaultWeight
+	return weight * n.warmup.Factor(n.start)
 }
 
 func (n *Node) PickElapsed() time.Duration {
@@ -68,3 +68,37 @@ func TestDirectDefaultWeight(t *testing.T) {
 		t.Errorf("time.Millisecond*5 >= wn.PickElapsed()(%s)", wn.PickElapsed())
 	}
 }
+
+func TestDirectWarmup(t *testing.T) {
+	b := &Builder{Warmup: &selector.Warmup{Duration: time.Hour}}
+	wn := b.Build(selector.NewNode(
+		"http",
+		"127.0.0.1:9090",
+		&registry.ServiceInstance{
+			ID:        "127.0.0.1:9090",
+			Name:      "helloworld",
+			Version:   "v1.0.0",
+			Endpoints: []string{"http://127.0.0.1:9090"},
+			Metadata:  map[string]string{"weight": "10"},
+		}))
+	if w := wn.Weight(); w >= 10 {
+		t.Errorf("expect warming weight below %v, got %v", 10, w)
+	}
+
+	wn = b.Build(selector.NewNode(
+		"http",
+		"127.0.0.1:9090",
+		&registry.ServiceInstance{
+			ID:        "127.0.0.1:9090",
+			Name:      "helloworld",
+			Version:   "v1.0.0",
+			Endpoints: []string{"http://127.0.0.1:9090"},
+			Metadata: map[string]string{
+				"weight":                 "10",
+				selector.RegisterTimeKey: time.Now().Add(-2 * time.Hour).Format(time.RFC3339),
+			},
+		}))
+	if w := wn.Weight(); w != 10 {
+		t.Errorf("expect %v, got %v", 10, w)
+	}
+}
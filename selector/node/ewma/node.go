@@ -41,6 +41,9 @@ type Node struct {
 
 	errHandler   func(err error) (isErr bool)
 	cachedWeight *atomic.Value
+
+	warmup *selector.Warmup
+	start  time.Time
 }
 
 type nodeWeight struct {
@@ -51,6 +54,8 @@ type nodeWeight struct {
 // Builder is ewma node builder.
 type Builder struct {
 	ErrHandler func(err error) (isErr bool)
+	// Warmup is the slow start config, nil means disabled.
+	Warmup *selector.Warmup
 }
 
 // Build create a weighted node.
@@ -62,6 +67,10 @@ func (b *Builder) Build(n selector.Node) selector.WeightedNode {
 		inflight:     1,
 		errHandler:   b.ErrHandler,
 		cachedWeight: &atomic.Value{},
+		warmup:       b.Warmup,
+	}
+	if b.Warmup != nil {
+		s.start = b.Warmup.StartTime(n)
 	}
 	return s
 }
@@ -179,7 +188,7 @@ func (n *Node) Weight() (weight float64) {
 	} else {
 		weight = w.value
 	}
-	return
+	return weight * n.warmup.Factor(n.start)
 }
 
 func (n *Node) PickElapsed() time.Duration {
@@ -23,7 +23,16 @@ var _ selector.Balancer = (*Balancer)(nil)
 type Option func(o *options)
 
 // options is p2c builder options
-type options struct{}
+type options struct {
+	warmup *selector.Warmup
+}
+
+// WithWarmup with the slow start window of newly added nodes.
+func WithWarmup(d time.Duration) Option {
+	return func(o *options) {
+		o.warmup = &selector.Warmup{Duration: d}
+	}
+}
 
 // New creates a p2c selector.
 func New(opts ...Option) selector.Selector {
@@ -87,7 +96,7 @@ func NewBuilder(opts ...Option) selector.Builder {
 	}
 	return &selector.DefaultBuilder{
 		Balancer: &Builder{},
-		Node:     &ewma.Builder{},
+		Node:     &ewma.Builder{Warmup: option.warmup},
 	}
 }
 
@@ -0,0 +1,234 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Rule is a traffic routing rule.
+type Rule struct {
+	// Name is the rule name.
+	Name string `json:"name"`
+	// Service is the target service name, empty matches any service.
+	Service string `json:"service"`
+	// Operation is the operation prefix, empty matches any operation.
+	Operation string `json:"operation"`
+	// Match is the request conditions, all of them must be met.
+	Match []Match `json:"match"`
+	// Routes is the weighted destinations of the matched requests.
+	Routes []Destination `json:"routes"`
+}
+
+// Match is a request header or metadata condition.
+type Match struct {
+	// Key is the request header or metadata key.
+	Key string `json:"key"`
+	// Exact matches the value exactly.
+	Exact string `json:"exact"`
+	// Prefix matches the value prefix.
+	Prefix string `json:"prefix"`
+	// Regex matches the value with a regular expression.
+	Regex string `json:"regex"`
+
+	re *regexp.Regexp
+}
+
+// Destination is a node subset selected by instance metadata labels.
+type Destination struct {
+	// Labels is the instance metadata the nodes must carry.
+	Labels map[string]string `json:"labels"`
+	// Weight is the relative weight of the destination.
+	Weight int `json:"weight"`
+}
+
+// Router is a routing rules engine.
+type Router struct {
+	rules atomic.Value
+}
+
+// New new a router with rules.
+func New(rules ...Rule) (*Router, error) {
+	r := &Router{}
+	if err := r.Update(rules); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update replaces the routing rules.
+func (r *Router) Update(rules []Rule) error {
+	newRules := make([]Rule, len(rules))
+	for i, rule := range rules {
+		matches := make([]Match, len(rule.Match))
+		for j, m := range rule.Match {
+			if m.Key == "" {
+				return fmt.Errorf("route: rule %q has a match without key", rule.Name)
+			}
+			if m.Regex != "" {
+				re, err := regexp.Compile(m.Regex)
+				if err != nil {
+					return fmt.Errorf("route: rule %q: %w", rule.Name, err)
+				}
+				m.re = re
+			}
+			matches[j] = m
+		}
+		for _, d := range rule.Routes {
+			if d.Weight < 0 {
+				return fmt.Errorf("route: rule %q has a negative weight", rule.Name)
+			}
+		}
+		rule.Match = matches
+		newRules[i] = rule
+	}
+	r.rules.Store(newRules)
+	return nil
+}
+
+// Rules returns the current routing rules.
+func (r *Router) Rules() []Rule {
+	rules, _ := r.rules.Load().([]Rule)
+	return rules
+}
+
+// Watch loads the rules from the config key and reloads them on change.
+func (r *Router) Watch(c config.Config, key string) error {
+	var rules []Rule
+	if err := c.Value(key).Scan(&rules); err != nil {
+		return err
+	}
+	if err := r.Update(rules); err != nil {
+		return err
+	}
+	return c.Watch(key, func(_ string, v config.Value) {
+		var rules []Rule
+		if err := v.Scan(&rules); err != nil {
+			log.Errorf("route: failed to scan rules: %v", err)
+			return
+		}
+		if err := r.Update(rules); err != nil {
+			log.Errorf("route: failed to update rules: %v", err)
+		}
+	})
+}
+
+// Filter returns a node filter that routes requests by the rules.
+func (r *Router) Filter() selector.NodeFilter {
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		if len(nodes) == 0 {
+			return nodes
+		}
+		rule, ok := r.match(ctx, nodes[0].ServiceName())
+		if !ok {
+			return nodes
+		}
+		dest, ok := pick(rule.Routes)
+		if !ok {
+			return nodes
+		}
+		newNodes := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			if hasLabels(n, dest.Labels) {
+				newNodes = append(newNodes, n)
+			}
+		}
+		if len(newNodes) == 0 {
+			return nodes
+		}
+		return newNodes
+	}
+}
+
+func (r *Router) match(ctx context.Context, service string) (Rule, bool) {
+	var operation string
+	tr, _ := transport.FromClientContext(ctx)
+	if tr != nil {
+		operation = tr.Operation()
+	}
+	md, _ := metadata.FromClientContext(ctx)
+	for _, rule := range r.Rules() {
+		if rule.Service != "" && rule.Service != service {
+			continue
+		}
+		if !strings.HasPrefix(operation, rule.Operation) {
+			continue
+		}
+		matched := true
+		for _, m := range rule.Match {
+			if !m.match(value(tr, md, m.Key)) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (m *Match) match(v string) bool {
+	switch {
+	case m.Exact != "":
+		return v == m.Exact
+	case m.Prefix != "":
+		return strings.HasPrefix(v, m.Prefix)
+	case m.re != nil:
+		return m.re.MatchString(v)
+	}
+	return v != ""
+}
+
+func value(tr transport.Transporter, md metadata.Metadata, key string) string {
+	if tr != nil {
+		if v := tr.RequestHeader().Get(key); v != "" {
+			return v
+		}
+	}
+	if md != nil {
+		return md.Get(key)
+	}
+	return ""
+}
+
+func pick(routes []Destination) (Destination, bool) {
+	var total int
+	for _, d := range routes {
+		total += d.Weight
+	}
+	if total == 0 {
+		return Destination{}, false
+	}
+	cur := rand.Intn(total)
+	for _, d := range routes {
+		if cur < d.Weight {
+			return d, true
+		}
+		cur -= d.Weight
+	}
+	return Destination{}, false
+}
+
+func hasLabels(n selector.Node, labels map[string]string) bool {
+	md := n.Metadata()
+	for k, v := range labels {
+		label, ok := md[k]
+		if !ok && k == "version" {
+			label = n.Version()
+		}
+		if label != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package route
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/file"
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+func (hc headerCarrier) Add(key, value string) { hc[key] = value }
+func (hc headerCarrier) Keys() []string        { return nil }
+func (hc headerCarrier) Values(string) []string {
+	return nil
+}
+
+type testTransport struct {
+	operation string
+	header    headerCarrier
+}
+
+func (tr *testTransport) Kind() transport.Kind               { return transport.KindHTTP }
+func (tr *testTransport) Endpoint() string                   { return "" }
+func (tr *testTransport) Operation() string                  { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header    { return tr.header }
+func (tr *testTransport) ReplyHeader() transport.Header      { return headerCarrier{} }
+func (tr *testTransport) NodeFilters() []selector.NodeFilter { return nil }
+
+func newNode(addr, version string, md map[string]string) selector.Node {
+	return selector.NewNode("http", addr, &registry.ServiceInstance{
+		ID:        addr,
+		Name:      "helloworld",
+		Version:   version,
+		Endpoints: []string{"http://" + addr},
+		Metadata:  md,
+	})
+}
+
+func testNodes() []selector.Node {
+	return []selector.Node{
+		newNode("127.0.0.1:9090", "v1", map[string]string{"lane": "stable"}),
+		newNode("127.0.0.2:9090", "v1", map[string]string{"lane": "stable"}),
+		newNode("127.0.0.3:9090", "v2", map[string]string{"lane": "canary"}),
+	}
+}
+
+func TestHeaderRouting(t *testing.T) {
+	r, err := New(Rule{
+		Name:      "test-traffic",
+		Operation: "/helloworld.Greeter/",
+		Match:     []Match{{Key: "x-env", Exact: "test"}},
+		Routes:    []Destination{{Labels: map[string]string{"lane": "canary"}, Weight: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := r.Filter()
+
+	ctx := transport.NewClientContext(context.Background(), &testTransport{
+		operation: "/helloworld.Greeter/SayHello",
+		header:    headerCarrier{"x-env": "test"},
+	})
+	nodes := filter(ctx, testNodes())
+	if len(nodes) != 1 || nodes[0].Address() != "127.0.0.3:9090" {
+		t.Errorf("expect canary node, got %v", nodes)
+	}
+
+	ctx = transport.NewClientContext(context.Background(), &testTransport{
+		operation: "/helloworld.Greeter/SayHello",
+		header:    headerCarrier{},
+	})
+	if nodes = filter(ctx, testNodes()); len(nodes) != 3 {
+		t.Errorf("expect %v, got %v", 3, len(nodes))
+	}
+
+	ctx = metadata.NewClientContext(ctx, metadata.New(map[string][]string{"x-env": {"test"}}))
+	if nodes = filter(ctx, testNodes()); len(nodes) != 1 {
+		t.Errorf("expect %v, got %v", 1, len(nodes))
+	}
+}
+
+func TestWeightedRouting(t *testing.T) {
+	r, err := New(Rule{
+		Name: "canary",
+		Routes: []Destination{
+			{Labels: map[string]string{"version": "v2"}, Weight: 20},
+			{Labels: map[string]string{"version": "v1"}, Weight: 80},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := r.Filter()
+	var canary int
+	for i := 0; i < 10000; i++ {
+		nodes := filter(context.Background(), testNodes())
+		if len(nodes) == 1 && nodes[0].Version() == "v2" {
+			canary++
+		}
+	}
+	if canary < 1500 || canary > 2500 {
+		t.Errorf("expect about %v canary picks, got %v", 2000, canary)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	r, err := New(Rule{
+		Service: "helloworld",
+		Match:   []Match{{Key: "x-user", Regex: "^u[0-9]+$"}},
+		Routes:  []Destination{{Labels: map[string]string{"lane": "missing"}, Weight: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.NewClientContext(context.Background(), metadata.New(map[string][]string{"x-user": {"u1"}}))
+	if nodes := r.Filter()(ctx, testNodes()); len(nodes) != 3 {
+		t.Errorf("expect %v, got %v", 3, len(nodes))
+	}
+}
+
+func TestInvalidRule(t *testing.T) {
+	if _, err := New(Rule{Match: []Match{{Key: "x-user", Regex: "("}}}); err == nil {
+		t.Error("expect regex error")
+	}
+	if _, err := New(Rule{Match: []Match{{Exact: "test"}}}); err == nil {
+		t.Error("expect key error")
+	}
+	if _, err := New(Rule{Routes: []Destination{{Weight: -1}}}); err == nil {
+		t.Error("expect weight error")
+	}
+}
+
+const (
+	testRules = `{"routes": [{"name": "canary", "routes": [{"labels": {"lane": "canary"}, "weight": 1}]}]}`
+	testNext  = `{"routes": [{"name": "stable", "routes": [{"labels": {"lane": "stable"}, "weight": 1}]}]}`
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "route.json")
+	if err := os.WriteFile(path, []byte(testRules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := config.New(config.WithSource(file.NewSource(path)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	r, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Watch(c, "routes"); err != nil {
+		t.Fatal(err)
+	}
+	if nodes := r.Filter()(context.Background(), testNodes()); len(nodes) != 1 {
+		t.Fatalf("expect %v, got %v", 1, len(nodes))
+	}
+
+	if err = os.WriteFile(path, []byte(testNext), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if rules := r.Rules(); len(rules) == 1 && rules[0].Name == "stable" {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if nodes := r.Filter()(context.Background(), testNodes()); len(nodes) != 2 {
+		t.Errorf("expect %v, got %v", 2, len(nodes))
+	}
+}
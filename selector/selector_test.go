@@ -289,3 +289,34 @@ func TestGlobalSelector(t *testing.T) {
 		t.Errorf("expect %v, got %v", nil, gBuilder)
 	}
 }
+
+func TestApplyKeepUnchanged(t *testing.T) {
+	d := &Default{
+		NodeBuilder: &mockWeightedNodeBuilder{},
+		Balancer:    &mockBalancer{},
+	}
+	newNodes := func(version string) []Node {
+		return []Node{
+			NewNode("http", "127.0.0.1:8080", &registry.ServiceInstance{
+				Name:     "helloworld",
+				Version:  "v1.0.0",
+				Metadata: map[string]string{"weight": "10"},
+			}),
+			NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{
+				Name:     "helloworld",
+				Version:  version,
+				Metadata: map[string]string{"weight": "10"},
+			}),
+		}
+	}
+	d.Apply(newNodes("v1.0.0"))
+	before := d.nodes.Load().([]WeightedNode)
+	d.Apply(newNodes("v2.0.0"))
+	after := d.nodes.Load().([]WeightedNode)
+	if before[0] != after[0] {
+		t.Errorf("expect unchanged node to be kept")
+	}
+	if before[1] == after[1] {
+		t.Errorf("expect changed node to be rebuilt")
+	}
+}
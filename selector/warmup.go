@@ -0,0 +1,65 @@
+package selector
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// RegisterTimeKey is the instance metadata key of the node registration time,
+// in unix seconds or RFC3339 format.
+const RegisterTimeKey = "register_time"
+
+// Warmup is the slow start config of weighted nodes,
+// the effective weight ramps up over Duration after the node appears.
+type Warmup struct {
+	// Duration is the warm-up window, zero value means warm-up disabled.
+	Duration time.Duration
+	// Aggression is the ramp curve, 1 or zero value means linear,
+	// a larger value gives more traffic at the beginning of the window.
+	Aggression float64
+	// MinRatio is the minimum ratio of the full weight during warm-up.
+	MinRatio float64
+}
+
+// StartTime returns the warm-up start time of the node,
+// it is the registration time in metadata if any, otherwise now.
+func (w *Warmup) StartTime(n Node) time.Time {
+	now := time.Now()
+	str, ok := n.Metadata()[RegisterTimeKey]
+	if !ok {
+		return now
+	}
+	if sec, err := strconv.ParseInt(str, 10, 64); err == nil {
+		if t := time.Unix(sec, 0); t.Before(now) {
+			return t
+		}
+		return now
+	}
+	if t, err := time.Parse(time.RFC3339, str); err == nil && t.Before(now) {
+		return t
+	}
+	return now
+}
+
+// Factor returns the weight factor in (0, 1] of the node started at start.
+func (w *Warmup) Factor(start time.Time) float64 {
+	if w == nil || w.Duration <= 0 {
+		return 1
+	}
+	elapsed := time.Since(start)
+	if elapsed >= w.Duration {
+		return 1
+	}
+	aggression := w.Aggression
+	if aggression <= 0 {
+		aggression = 1
+	}
+	factor := math.Pow(float64(elapsed)/float64(w.Duration), 1/aggression)
+	minRatio := w.MinRatio
+	if minRatio <= 0 {
+		// keep a tiny weight so a fresh node still gets probed
+		minRatio = 0.01
+	}
+	return math.Max(factor, minRatio)
+}
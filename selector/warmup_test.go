@@ -0,0 +1,59 @@
+package selector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+func TestWarmupFactor(t *testing.T) {
+	var disabled *Warmup
+	if f := disabled.Factor(time.Now()); f != 1 {
+		t.Errorf("expect %v, got %v", 1, f)
+	}
+	w := &Warmup{Duration: time.Minute}
+	if f := w.Factor(time.Now().Add(-30 * time.Second)); f < 0.49 || f > 0.51 {
+		t.Errorf("expect %v, got %v", 0.5, f)
+	}
+	if f := w.Factor(time.Now().Add(-2 * time.Minute)); f != 1 {
+		t.Errorf("expect %v, got %v", 1, f)
+	}
+	if f := w.Factor(time.Now()); f != 0.01 {
+		t.Errorf("expect %v, got %v", 0.01, f)
+	}
+	w = &Warmup{Duration: time.Minute, Aggression: 2, MinRatio: 0.1}
+	if f := w.Factor(time.Now().Add(-15 * time.Second)); f < 0.49 || f > 0.51 {
+		t.Errorf("expect %v, got %v", 0.5, f)
+	}
+	if f := w.Factor(time.Now()); f != 0.1 {
+		t.Errorf("expect %v, got %v", 0.1, f)
+	}
+}
+
+func TestWarmupStartTime(t *testing.T) {
+	w := &Warmup{Duration: time.Minute}
+	registered := time.Now().Add(-time.Hour).Truncate(time.Second)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{strconv.FormatInt(registered.Unix(), 10), registered},
+		{registered.Format(time.RFC3339), registered},
+	}
+	for _, test := range tests {
+		n := NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{
+			Metadata: map[string]string{RegisterTimeKey: test.value},
+		})
+		if got := w.StartTime(n); !got.Equal(test.want) {
+			t.Errorf("expect %v, got %v", test.want, got)
+		}
+	}
+	n := NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{
+		Metadata: map[string]string{RegisterTimeKey: "invalid"},
+	})
+	if got := w.StartTime(n); time.Since(got) > time.Second {
+		t.Errorf("expect now, got %v", got)
+	}
+}
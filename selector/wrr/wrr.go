@@ -3,6 +3,7 @@ package wrr
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/selector"
 	"github.com/go-kratos/kratos/v2/selector/node/direct"
@@ -19,7 +20,16 @@ var _ selector.Balancer = (*Balancer)(nil) // Name is balancer name
 type Option func(o *options)
 
 // options is wrr builder options
-type options struct{}
+type options struct {
+	warmup *selector.Warmup
+}
+
+// WithWarmup with the slow start window of newly added nodes.
+func WithWarmup(d time.Duration) Option {
+	return func(o *options) {
+		o.warmup = &selector.Warmup{Duration: d}
+	}
+}
 
 // Balancer is a wrr balancer.
 type Balancer struct {
@@ -69,7 +79,7 @@ func NewBuilder(opts ...Option) selector.Builder {
 	}
 	return &selector.DefaultBuilder{
 		Balancer: &Builder{},
-		Node:     &direct.Builder{},
+		Node:     &direct.Builder{Warmup: option.warmup},
 	}
 }
 
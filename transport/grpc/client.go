@@ -3,13 +3,19 @@ package grpc
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	grpcinsecure "google.golang.org/grpc/credentials/insecure"
 	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
@@ -54,13 +60,23 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
-// WithMiddleware with client middleware.
+// WithMiddleware with client middleware, it's invoked for each unary RPC and once for the whole
+// streaming RPC with a nil request, see middleware.IsStream. The middleware of the whole stream returns
+// when the stream finishes, its context is done or the connection is closed.
 func WithMiddleware(m ...middleware.Middleware) ClientOption {
 	return func(o *clientOptions) {
 		o.middleware = m
 	}
 }
 
+// WithStreamMiddleware with client middleware of the stream messages, it's invoked for each message
+// sent and received on the streaming RPCs, see middleware.StreamCallMessage.
+func WithStreamMiddleware(m ...middleware.Middleware) ClientOption {
+	return func(o *clientOptions) {
+		o.streamMiddleware = m
+	}
+}
+
 // WithDiscovery with client discovery.
 func WithDiscovery(d registry.Discovery) ClientOption {
 	return func(o *clientOptions) {
@@ -132,6 +148,7 @@ type clientOptions struct {
 	timeout                time.Duration
 	discovery              registry.Discovery
 	middleware             []middleware.Middleware
+	streamMiddleware       []middleware.Middleware
 	ints                   []grpc.UnaryClientInterceptor
 	streamInts             []grpc.StreamClientInterceptor
 	grpcOpts               []grpc.DialOption
@@ -166,7 +183,7 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 		unaryClientInterceptor(options.middleware, options.timeout, options.filters),
 	}
 	sints := []grpc.StreamClientInterceptor{
-		streamClientInterceptor(options.filters),
+		streamClientInterceptor(options.middleware, options.streamMiddleware, options.filters),
 	}
 
 	if len(options.ints) > 0 {
@@ -239,7 +256,9 @@ func unaryClientInterceptor(ms []middleware.Middleware, timeout time.Duration, f
 	}
 }
 
-func streamClientInterceptor(filters []selector.NodeFilter) grpc.StreamClientInterceptor {
+// streamClientInterceptor is a gRPC stream client interceptor, the client middleware
+// wraps the whole stream with a nil request and the stream middleware each message of the stream.
+func streamClientInterceptor(next, ms []middleware.Middleware, filters []selector.NodeFilter) grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) { // nolint
 		ctx = transport.NewClientContext(ctx, &Transport{
 			endpoint:    cc.Target(),
@@ -249,6 +268,156 @@ func streamClientInterceptor(filters []selector.NodeFilter) grpc.StreamClientInt
 		})
 		var p selector.Peer
 		ctx = selector.NewPeerContext(ctx, &p)
-		return streamer(ctx, desc, cc, method, opts...)
+
+		created := make(chan grpc.ClientStream, 1)
+		h := func(ctx context.Context, _ interface{}) (interface{}, error) {
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				header := tr.RequestHeader()
+				keys := header.Keys()
+				keyvals := make([]string, 0, len(keys))
+				for _, k := range keys {
+					keyvals = append(keyvals, k, header.Get(k))
+				}
+				ctx = grpcmd.AppendToOutgoingContext(ctx, keyvals...)
+			}
+			cs, err := streamer(ctx, desc, cc, method, opts...)
+			if err != nil {
+				return nil, err
+			}
+			ws := newWrappedClientStream(ctx, cc, cs, desc, ms)
+			created <- ws
+			if len(next) == 0 {
+				return nil, nil
+			}
+			// hold the middleware until the stream finishes
+			return nil, ws.wait()
+		}
+		if len(next) == 0 {
+			if _, err := h(ctx, nil); err != nil {
+				return nil, err
+			}
+			return <-created, nil
+		}
+		h = middleware.Chain(next...)(h)
+		result := make(chan error, 1)
+		go func() {
+			_, err := h(middleware.NewStreamContext(ctx, middleware.StreamCallWhole), nil)
+			result <- err
+		}()
+		select {
+		case cs := <-created:
+			return cs, nil
+		case <-ctx.Done():
+			// the middleware returns once the handler observes the canceled context
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case err := <-result:
+			select {
+			case cs := <-created:
+				return cs, nil
+			default:
+			}
+			if err == nil {
+				err = status.Error(codes.Internal, "grpc: middleware returned without creating the stream")
+			}
+			return nil, err
+		}
+	}
+}
+
+// wrappedClientStream tracks the lifetime of a client stream.
+type wrappedClientStream struct {
+	grpc.ClientStream
+	ctx        context.Context
+	cc         *grpc.ClientConn
+	desc       *grpc.StreamDesc
+	middleware []middleware.Middleware
+
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+func newWrappedClientStream(ctx context.Context, cc *grpc.ClientConn, cs grpc.ClientStream, desc *grpc.StreamDesc, ms []middleware.Middleware) *wrappedClientStream {
+	return &wrappedClientStream{
+		ClientStream: cs,
+		ctx:          middleware.NewStreamContext(ctx, middleware.StreamCallMessage),
+		cc:           cc,
+		desc:         desc,
+		middleware:   ms,
+		done:         make(chan struct{}),
+	}
+}
+
+// Header returns the header metadata of the stream, the stream finishes if it fails.
+func (w *wrappedClientStream) Header() (grpcmd.MD, error) {
+	md, err := w.ClientStream.Header()
+	if err != nil {
+		w.finish(err)
+	}
+	return md, err
+}
+
+// RecvMsg receives a message through the stream middleware.
+func (w *wrappedClientStream) RecvMsg(m interface{}) error {
+	var err error
+	if len(w.middleware) == 0 {
+		err = w.ClientStream.RecvMsg(m)
+	} else {
+		h := func(_ context.Context, req interface{}) (interface{}, error) {
+			return req, w.ClientStream.RecvMsg(req)
+		}
+		_, err = middleware.Chain(w.middleware...)(h)(w.ctx, m)
+	}
+	if err != nil || !w.desc.ServerStreams {
+		w.finish(err)
+	}
+	return err
+}
+
+// SendMsg sends a message through the stream middleware, the stream finishes if it fails
+// with an error other than io.EOF, which is returned by RecvMsg then.
+func (w *wrappedClientStream) SendMsg(m interface{}) error {
+	var err error
+	if len(w.middleware) == 0 {
+		err = w.ClientStream.SendMsg(m)
+	} else {
+		h := func(_ context.Context, req interface{}) (interface{}, error) {
+			return req, w.ClientStream.SendMsg(req)
+		}
+		_, err = middleware.Chain(w.middleware...)(h)(w.ctx, m)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		w.finish(err)
+	}
+	return err
+}
+
+func (w *wrappedClientStream) finish(err error) {
+	w.once.Do(func() {
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		w.err = err
+		close(w.done)
+	})
+}
+
+// wait returns when the stream finishes. gRPC releases the stream once it's drained, fails,
+// its context is done or the connection is closed, so the context of the stream is done then.
+func (w *wrappedClientStream) wait() error {
+	select {
+	case <-w.done:
+	case <-w.ctx.Done():
+		w.finish(w.ctx.Err())
+	case <-w.ClientStream.Context().Done():
+		switch {
+		case w.ctx.Err() != nil:
+			w.finish(w.ctx.Err())
+		case w.cc.GetState() == connectivity.Shutdown:
+			w.finish(status.Error(codes.Canceled, "grpc: the client connection is closing"))
+		}
+		// otherwise the stream is finished by the call that observed its error
+		<-w.done
 	}
+	return w.err
 }
@@ -2,11 +2,16 @@ package grpc
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
 	ic "github.com/go-kratos/kratos/v2/internal/context"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
 )
@@ -26,9 +31,19 @@ func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 		if s.endpoint != nil {
 			tr.endpoint = s.endpoint.String()
 		}
+		tr.peer = s.tlsPeer(ctx)
 		ctx = transport.NewServerContext(ctx, tr)
-		if s.timeout > 0 {
-			ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		// the deadline of the client is propagated by the grpc-timeout header
+		if deadline, ok := ctx.Deadline(); ok && s.propagate && time.Until(deadline) <= 0 {
+			s.deadlineExhausted(ctx, tr.Operation())
+			return nil, transport.ErrDeadlineExceeded
+		}
+		timeout := s.timeout
+		if t, ok := s.timeouts.Timeout(tr.Operation()); ok {
+			timeout = t
+		}
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
 			defer cancel()
 		}
 		h := func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -48,7 +63,8 @@ func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 // wrappedStream is rewrite grpc stream's context
 type wrappedStream struct {
 	grpc.ServerStream
-	ctx context.Context
+	ctx        context.Context
+	middleware []middleware.Middleware
 }
 
 func NewWrappedStream(ctx context.Context, stream grpc.ServerStream) grpc.ServerStream {
@@ -62,26 +78,95 @@ func (w *wrappedStream) Context() context.Context {
 	return w.ctx
 }
 
-// streamServerInterceptor is a gRPC stream server interceptor
+// RecvMsg receives a message through the stream middleware.
+func (w *wrappedStream) RecvMsg(m interface{}) error {
+	if len(w.middleware) == 0 {
+		return w.ServerStream.RecvMsg(m)
+	}
+	h := func(_ context.Context, req interface{}) (interface{}, error) {
+		return req, w.ServerStream.RecvMsg(req)
+	}
+	ctx := middleware.NewStreamContext(w.ctx, middleware.StreamCallMessage)
+	_, err := middleware.Chain(w.middleware...)(h)(ctx, m)
+	return err
+}
+
+// SendMsg sends a message through the stream middleware.
+func (w *wrappedStream) SendMsg(m interface{}) error {
+	if len(w.middleware) == 0 {
+		return w.ServerStream.SendMsg(m)
+	}
+	h := func(_ context.Context, req interface{}) (interface{}, error) {
+		return req, w.ServerStream.SendMsg(req)
+	}
+	ctx := middleware.NewStreamContext(w.ctx, middleware.StreamCallMessage)
+	_, err := middleware.Chain(w.middleware...)(h)(ctx, m)
+	return err
+}
+
+// deadlineExhausted logs the request rejected because its deadline budget is exhausted.
+func (s *Server) deadlineExhausted(ctx context.Context, operation string) {
+	log.Context(ctx).Warnw("msg", "request rejected by the exhausted deadline budget", "kind", transport.KindGRPC.String(), "operation", operation)
+	if s.exhausted != nil {
+		s.exhausted(ctx, transport.KindGRPC, operation)
+	}
+}
+
+// streamServerInterceptor is a gRPC stream server interceptor, the server middleware
+// wraps the whole stream with a nil request and the stream middleware each message of the stream.
 func (s *Server) streamServerInterceptor() grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		ctx, cancel := ic.Merge(ss.Context(), s.baseCtx)
 		defer cancel()
 		md, _ := grpcmd.FromIncomingContext(ctx)
 		replyHeader := grpcmd.MD{}
-		ctx = transport.NewServerContext(ctx, &Transport{
-			endpoint:    s.endpoint.String(),
+		tr := &Transport{
 			operation:   info.FullMethod,
 			reqHeader:   headerCarrier(md),
 			replyHeader: headerCarrier(replyHeader),
-		})
-
-		ws := NewWrappedStream(ctx, ss)
+		}
+		if s.endpoint != nil {
+			tr.endpoint = s.endpoint.String()
+		}
+		tr.peer = s.tlsPeer(ctx)
+		ctx = transport.NewServerContext(ctx, tr)
 
-		err := handler(srv, ws)
+		var next, ms []middleware.Middleware
+		if !isInternalStream(tr.Operation()) {
+			next = s.middleware.Match(tr.Operation())
+			ms = s.streamMiddleware.Match(tr.Operation())
+		}
+		h := func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return nil, handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx, middleware: ms})
+		}
+		if len(next) > 0 {
+			h = middleware.Chain(next...)(h)
+		}
+		_, err := h(middleware.NewStreamContext(ctx, middleware.StreamCallWhole), nil)
 		if len(replyHeader) > 0 {
 			_ = grpc.SetHeader(ctx, replyHeader)
 		}
 		return err
 	}
 }
+
+// isInternalStream reports whether the stream is served by the internal registered services,
+// e.g. the health watch used by the client health checking must not be rejected by the middleware.
+func isInternalStream(operation string) bool {
+	return strings.HasPrefix(operation, "/grpc.health.v1.Health/") ||
+		strings.HasPrefix(operation, "/grpc.reflection.")
+}
+
+// tlsPeer returns the identity of the verified client certificate of the connection.
+func (s *Server) tlsPeer(ctx context.Context) *transport.Peer {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	tp, _ := transport.PeerFromTLS(&info.State, s.tlsConf)
+	return tp
+}
@@ -59,19 +59,54 @@ func Timeout(timeout time.Duration) ServerOption {
 	}
 }
 
+// OperationTimeouts with the per-operation timeouts overriding the server timeout,
+// keyed by the full method name, e.g. /helloworld.Greeter/SayHello.
+func OperationTimeouts(timeouts *transport.Timeouts) ServerOption {
+	return func(s *Server) {
+		s.timeouts = timeouts
+	}
+}
+
+// DeadlinePropagation with the deadline budget of the callers honoured, the requests of the exhausted
+// budget are rejected with transport.ErrDeadlineExceeded before the middleware, as the HTTP server does.
+// The deadline propagated by the grpc-timeout header shortens the server timeout regardless, as it's
+// applied by gRPC itself.
+func DeadlinePropagation() ServerOption {
+	return func(s *Server) {
+		s.propagate = true
+	}
+}
+
+// DeadlineExhaustedHandler with the func called for the requests rejected because their deadline
+// budget is exhausted, e.g. metrics.DeadlineExhaustedCounter, the requests are logged anyway.
+func DeadlineExhaustedHandler(fn transport.DeadlineExhaustedFunc) ServerOption {
+	return func(s *Server) {
+		s.exhausted = fn
+	}
+}
+
 // Logger with server logger.
 // Deprecated: use global logger instead.
 func Logger(_ log.Logger) ServerOption {
 	return func(s *Server) {}
 }
 
-// Middleware with server middleware.
+// Middleware with server middleware, it's invoked for each unary RPC and once for the whole
+// streaming RPC with a nil request, see middleware.IsStream.
 func Middleware(m ...middleware.Middleware) ServerOption {
 	return func(s *Server) {
 		s.middleware.Use(m...)
 	}
 }
 
+// StreamMiddleware with server middleware of the stream messages, it's invoked for each message
+// received and sent on the streaming RPCs, see middleware.StreamCallMessage.
+func StreamMiddleware(m ...middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.streamMiddleware.Use(m...)
+	}
+}
+
 // CustomHealth Checks server.
 func CustomHealth() ServerOption {
 	return func(s *Server) {
@@ -117,33 +152,38 @@ func Options(opts ...grpc.ServerOption) ServerOption {
 // Server is a gRPC server wrapper.
 type Server struct {
 	*grpc.Server
-	baseCtx      context.Context
-	tlsConf      *tls.Config
-	lis          net.Listener
-	err          error
-	network      string
-	address      string
-	endpoint     *url.URL
-	timeout      time.Duration
-	middleware   matcher.Matcher
-	unaryInts    []grpc.UnaryServerInterceptor
-	streamInts   []grpc.StreamServerInterceptor
-	grpcOpts     []grpc.ServerOption
-	health       *health.Server
-	customHealth bool
-	metadata     *apimd.Server
-	adminClean   func()
+	baseCtx          context.Context
+	tlsConf          *tls.Config
+	lis              net.Listener
+	err              error
+	network          string
+	address          string
+	endpoint         *url.URL
+	timeout          time.Duration
+	timeouts         *transport.Timeouts
+	propagate        bool
+	exhausted        transport.DeadlineExhaustedFunc
+	middleware       matcher.Matcher
+	streamMiddleware matcher.Matcher
+	unaryInts        []grpc.UnaryServerInterceptor
+	streamInts       []grpc.StreamServerInterceptor
+	grpcOpts         []grpc.ServerOption
+	health           *health.Server
+	customHealth     bool
+	metadata         *apimd.Server
+	adminClean       func()
 }
 
 // NewServer creates a gRPC server by options.
 func NewServer(opts ...ServerOption) *Server {
 	srv := &Server{
-		baseCtx:    context.Background(),
-		network:    "tcp",
-		address:    ":0",
-		timeout:    1 * time.Second,
-		health:     health.NewServer(),
-		middleware: matcher.New(),
+		baseCtx:          context.Background(),
+		network:          "tcp",
+		address:          ":0",
+		timeout:          1 * time.Second,
+		health:           health.NewServer(),
+		middleware:       matcher.New(),
+		streamMiddleware: matcher.New(),
 	}
 	for _, o := range opts {
 		o(srv)
@@ -192,6 +232,12 @@ func (s *Server) Use(selector string, m ...middleware.Middleware) {
 	s.middleware.Add(selector, m...)
 }
 
+// UseStream uses a service stream middleware with selector,
+// it's invoked for the messages of the matched streaming RPCs, see StreamMiddleware.
+func (s *Server) UseStream(selector string, m ...middleware.Middleware) {
+	s.streamMiddleware.Add(selector, m...)
+}
+
 // Endpoint return a real address to registry endpoint.
 // examples:
 //
@@ -3,15 +3,20 @@ package grpc
 import (
 	"context"
 	"crypto/tls"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/internal/matcher"
@@ -280,6 +285,47 @@ func TestServer_unaryServerInterceptor(t *testing.T) {
 	}
 }
 
+func TestServer_unaryServerInterceptorTimeout(t *testing.T) {
+	srv := &Server{
+		baseCtx:    context.Background(),
+		timeout:    time.Minute,
+		timeouts:   transport.NewTimeouts(map[string]time.Duration{"/test.Service/*": time.Second}),
+		middleware: matcher.New(),
+		propagate:  true,
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Get"}
+	_, err := srv.unaryServerInterceptor()(context.Background(), nil, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if deadline, _ := ctx.Deadline(); time.Until(deadline) > time.Second {
+			t.Errorf("expect the operation timeout, got %v", time.Until(deadline))
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = srv.unaryServerInterceptor()(ctx, nil, info, func(context.Context, interface{}) (interface{}, error) {
+		t.Error("expect the exhausted request rejected")
+		return nil, nil
+	})
+	if !errors.Is(err, transport.ErrDeadlineExceeded) {
+		t.Errorf("expect %v, got %v", transport.ErrDeadlineExceeded, err)
+	}
+
+	// the exhausted request is left to the handler without the deadline propagation
+	srv.propagate = false
+	called := false
+	_, err = srv.unaryServerInterceptor()(ctx, nil, info, func(context.Context, interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	if err != nil || !called {
+		t.Errorf("expect the handler called, got %v", err)
+	}
+}
+
 func TestListener(t *testing.T) {
 	lis, err := net.Listen("tcp", ":0")
 	if err != nil {
@@ -294,3 +340,260 @@ func TestListener(t *testing.T) {
 		t.Errorf("expect not empty")
 	}
 }
+
+func TestStreamMiddleware(t *testing.T) {
+	var (
+		serverHeader = make(chan string, 1)
+		serverDone   = make(chan error, 1)
+		serverMsgs   int32
+		clientDone   = make(chan error, 1)
+		clientMsgs   int32
+	)
+	srv := NewServer(
+		Middleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				if !middleware.IsStream(ctx) || req != nil {
+					t.Errorf("expect the whole stream, got %v", req)
+				}
+				tr, _ := transport.FromServerContext(ctx)
+				serverHeader <- tr.RequestHeader().Get("x-md-stream")
+				reply, err := handler(ctx, req)
+				serverDone <- err
+				return reply, err
+			}
+		}),
+		StreamMiddleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				if call, _ := middleware.StreamFromContext(ctx); call != middleware.StreamCallMessage {
+					t.Errorf("expect %v, got %v", middleware.StreamCallMessage, call)
+				}
+				if _, ok := req.(*pb.HelloRequest); ok {
+					atomic.AddInt32(&serverMsgs, 1)
+				}
+				return handler(ctx, req)
+			}
+		}),
+	)
+	pb.RegisterGreeterServer(srv, &server{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := srv.Start(context.Background()); err != nil {
+			panic(err)
+		}
+	}()
+	defer func() { _ = srv.Stop(context.Background()) }()
+	time.Sleep(time.Second)
+
+	conn, err := DialInsecure(context.Background(),
+		WithEndpoint(u.Host),
+		WithMiddleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				if !middleware.IsStream(ctx) || req != nil {
+					t.Errorf("expect the whole stream, got %v", req)
+				}
+				if tr, ok := transport.FromClientContext(ctx); ok {
+					tr.RequestHeader().Set("x-md-stream", "stream")
+				}
+				reply, err := handler(ctx, req)
+				clientDone <- err
+				return reply, err
+			}
+		}),
+		WithStreamMiddleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				if call, _ := middleware.StreamFromContext(ctx); call != middleware.StreamCallMessage {
+					t.Errorf("expect %v, got %v", middleware.StreamCallMessage, call)
+				}
+				reply, err := handler(ctx, req)
+				if _, ok := req.(*pb.HelloReply); ok && err == nil {
+					atomic.AddInt32(&clientMsgs, 1)
+				}
+				return reply, err
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	stream, err := pb.NewGreeterClient(conn).SayHelloStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		if err = stream.Send(&pb.HelloRequest{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = stream.Recv(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = stream.Recv(); err != io.EOF {
+		t.Fatalf("expect %v, got %v", io.EOF, err)
+	}
+
+	if header := <-serverHeader; header != "stream" {
+		t.Errorf("expect %v, got %v", "stream", header)
+	}
+	if err = <-serverDone; err != nil {
+		t.Errorf("expect %v, got %v", nil, err)
+	}
+	if err = <-clientDone; err != nil {
+		t.Errorf("expect %v, got %v", nil, err)
+	}
+	if n := atomic.LoadInt32(&serverMsgs); n != 2 {
+		t.Errorf("expect %v, got %v", 2, n)
+	}
+	if n := atomic.LoadInt32(&clientMsgs); n != 2 {
+		t.Errorf("expect %v, got %v", 2, n)
+	}
+}
+
+func TestStreamMiddlewareReject(t *testing.T) {
+	srv := NewServer(
+		Middleware(func(middleware.Handler) middleware.Handler {
+			return func(context.Context, interface{}) (interface{}, error) {
+				return nil, errors.Unauthorized("UNAUTHORIZED", "")
+			}
+		}),
+	)
+	pb.RegisterGreeterServer(srv, &server{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := srv.Start(context.Background()); err != nil {
+			panic(err)
+		}
+	}()
+	defer func() { _ = srv.Stop(context.Background()) }()
+	time.Sleep(time.Second)
+
+	conn, err := DialInsecure(context.Background(), WithEndpoint(u.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	stream, err := pb.NewGreeterClient(conn).SayHelloStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = stream.Send(&pb.HelloRequest{Name: "a"})
+	if _, err = stream.Recv(); !errors.IsUnauthorized(err) {
+		t.Errorf("expect unauthorized, got %v", err)
+	}
+
+	// client middleware rejects before the stream is created
+	conn, err = DialInsecure(context.Background(), WithEndpoint(u.Host),
+		WithMiddleware(func(middleware.Handler) middleware.Handler {
+			return func(context.Context, interface{}) (interface{}, error) {
+				return nil, errors.ServiceUnavailable("CIRCUITBREAKER", "")
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err = pb.NewGreeterClient(conn).SayHelloStream(context.Background()); !errors.IsServiceUnavailable(err) {
+		t.Errorf("expect service unavailable, got %v", err)
+	}
+}
+
+func TestStreamMiddlewareCancel(t *testing.T) {
+	srv := NewServer()
+	pb.RegisterGreeterServer(srv, &server{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := srv.Start(context.Background()); err != nil {
+			panic(err)
+		}
+	}()
+	defer func() { _ = srv.Stop(context.Background()) }()
+	time.Sleep(time.Second)
+
+	var (
+		block = make(chan struct{}, 1)
+		done  = make(chan error, 2)
+	)
+	mw := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			reply, err := handler(ctx, req)
+			done <- err
+			return reply, err
+		}
+	}
+	conn, err := DialInsecure(context.Background(), WithEndpoint(u.Host),
+		WithMiddleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				select {
+				case <-block:
+					<-ctx.Done()
+				default:
+				}
+				reply, err := handler(ctx, req)
+				done <- err
+				return reply, err
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// the stream left unread is released by canceling its context
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err = pb.NewGreeterClient(conn).SayHelloStream(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case err = <-done:
+		if !stderrors.Is(err, context.Canceled) {
+			t.Errorf("expect %v, got %v", context.Canceled, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the stream middleware isn't released")
+	}
+
+	// the middleware blocking before the stream is created doesn't block the caller
+	block <- struct{}{}
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err = pb.NewGreeterClient(conn).SayHelloStream(ctx); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("expect %v, got %v", codes.DeadlineExceeded, err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the stream middleware isn't released")
+	}
+
+	// the stream left unread and uncanceled is released by closing the connection
+	closed, err := DialInsecure(context.Background(), WithEndpoint(u.Host), WithMiddleware(mw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pb.NewGreeterClient(closed).SayHelloStream(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_ = closed.Close()
+	select {
+	case err = <-done:
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("expect %v, got %v", codes.Canceled, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the stream middleware isn't released")
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/go-kratos/kratos/v2/transport"
 )
 
-var _ transport.Transporter = (*Transport)(nil)
+var _ transport.PeerTransporter = (*Transport)(nil)
 
 // Transport is a gRPC transport.
 type Transport struct {
@@ -16,6 +16,7 @@ type Transport struct {
 	reqHeader   headerCarrier
 	replyHeader headerCarrier
 	nodeFilters []selector.NodeFilter
+	peer        *transport.Peer
 }
 
 // Kind returns the transport kind.
@@ -43,6 +44,11 @@ func (tr *Transport) ReplyHeader() transport.Header {
 	return tr.replyHeader
 }
 
+// Peer returns the identity of the verified client certificate.
+func (tr *Transport) Peer() (*transport.Peer, bool) {
+	return tr.peer, tr.peer != nil
+}
+
 // NodeFilters returns the client select filters.
 func (tr *Transport) NodeFilters() []selector.NodeFilter {
 	return tr.nodeFilters
@@ -54,6 +54,7 @@ type clientOptions struct {
 	middleware   []middleware.Middleware
 	block        bool
 	subsetSize   int
+	propagate    bool
 }
 
 // WithSubset with client discovery subset size.
@@ -78,6 +79,14 @@ func WithTimeout(d time.Duration) ClientOption {
 	}
 }
 
+// WithDeadlinePropagation with the remaining deadline budget of the requests sent in the
+// X-Kratos-Timeout header, it should only be enabled for the kratos servers with DeadlinePropagation.
+func WithDeadlinePropagation() ClientOption {
+	return func(o *clientOptions) {
+		o.propagate = true
+	}
+}
+
 // WithUserAgent with client user agent.
 func WithUserAgent(ua string) ClientOption {
 	return func(o *clientOptions) {
@@ -309,6 +318,9 @@ func (client *Client) do(req *http.Request) (*http.Response, error) {
 		req.URL.Host = node.Address()
 		req.Host = node.Address()
 	}
+	if budget, ok := client.budget(req.Context()); ok && client.opts.propagate {
+		req.Header.Set(TimeoutHeader, formatTimeout(budget))
+	}
 	resp, err := client.cc.Do(req)
 	if err == nil {
 		err = client.opts.errorDecoder(req.Context(), resp)
@@ -322,8 +334,23 @@ func (client *Client) do(req *http.Request) (*http.Response, error) {
 	return resp, nil
 }
 
+// budget returns the remaining deadline budget of the request,
+// the shorter of the client timeout and the deadline of ctx.
+func (client *Client) budget(ctx context.Context) (time.Duration, bool) {
+	budget, ok := client.opts.timeout, client.opts.timeout > 0
+	if deadline, has := ctx.Deadline(); has {
+		if remaining := time.Until(deadline); !ok || remaining < budget {
+			budget, ok = remaining, true
+		}
+	}
+	return budget, ok
+}
+
 // Close tears down the Transport and all underlying connections.
 func (client *Client) Close() error {
+	if c, ok := client.selector.(io.Closer); ok {
+		_ = c.Close()
+	}
 	if client.r != nil {
 		return client.r.Close()
 	}
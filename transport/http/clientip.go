@@ -0,0 +1,59 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIP returns the client IP of the request. It's the remote address unless the remote address
+// is one of the trusted proxies, then it's the right-most address of the X-Forwarded-For header
+// not from the trusted proxies, as the proxies append to the header while the left-most addresses
+// are set by the client. The X-Real-IP header is used if the trusted proxy doesn't set X-Forwarded-For.
+func ClientIP(req *http.Request, trustedProxies ...netip.Prefix) string {
+	remote := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !trusted(remote, trustedProxies) {
+		return remote
+	}
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	client := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		client = hop
+		if !trusted(hop, trustedProxies) {
+			return hop
+		}
+	}
+	if client != "" {
+		// all the hops are the trusted proxies
+		return client
+	}
+	if ip := strings.TrimSpace(req.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	return remote
+}
+
+func trusted(ip string, proxies []netip.Prefix) bool {
+	if len(proxies) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
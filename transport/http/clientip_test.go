@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	tests := []struct {
+		name    string
+		remote  string
+		xff     string
+		realIP  string
+		proxies []netip.Prefix
+		want    string
+	}{
+		{name: "remote", remote: "1.2.3.4:80", want: "1.2.3.4"},
+		{name: "untrusted remote", remote: "1.2.3.4:80", xff: "5.6.7.8", want: "1.2.3.4"},
+		{name: "no proxies", remote: "10.0.0.1:80", xff: "5.6.7.8", want: "10.0.0.1"},
+		{name: "spoofed", remote: "10.0.0.1:80", xff: "9.9.9.9, 5.6.7.8", proxies: proxies, want: "5.6.7.8"},
+		{name: "proxy chain", remote: "10.0.0.1:80", xff: "5.6.7.8, 10.0.0.2", proxies: proxies, want: "5.6.7.8"},
+		{name: "all trusted", remote: "10.0.0.1:80", xff: "10.0.0.3, 10.0.0.2", proxies: proxies, want: "10.0.0.3"},
+		{name: "real ip", remote: "10.0.0.1:80", realIP: "5.6.7.8", proxies: proxies, want: "5.6.7.8"},
+		{name: "no port", remote: "1.2.3.4", want: "1.2.3.4"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = test.remote
+			if test.xff != "" {
+				req.Header.Set("X-Forwarded-For", test.xff)
+			}
+			if test.realIP != "" {
+				req.Header.Set("X-Real-IP", test.realIP)
+			}
+			if got := ClientIP(req, test.proxies...); got != test.want {
+				t.Errorf("expect %v, got %v", test.want, got)
+			}
+		})
+	}
+}
@@ -93,7 +93,8 @@ func (c *wrapper) Request() *http.Request        { return c.req }
 func (c *wrapper) Response() http.ResponseWriter { return c.res }
 func (c *wrapper) Middleware(h middleware.Handler) middleware.Handler {
 	if tr, ok := transport.FromServerContext(c.req.Context()); ok {
-		return middleware.Chain(c.router.srv.middleware.Match(tr.Operation())...)(h)
+		h = middleware.Chain(c.router.srv.middleware.Match(tr.Operation())...)(h)
+		return c.router.srv.operationTimeout(tr, h)
 	}
 	return middleware.Chain(c.router.srv.middleware.Match(c.req.URL.Path)...)(h)
 }
@@ -105,14 +106,30 @@ func (c *wrapper) Returns(v interface{}, err error) error {
 	if err != nil {
 		return err
 	}
-	return c.router.srv.enc(&c.w, c.req, v)
+	return c.Result(c.w.code, v)
 }
 
 func (c *wrapper) Result(code int, v interface{}) error {
+	if c.req != nil {
+		if tr, ok := transport.FromServerContext(c.req.Context()); ok {
+			if tr, ok := tr.(*Transport); ok && tr.replyStatus != 0 {
+				code = tr.replyStatus
+			}
+		}
+	}
+	if !bodyAllowed(code) {
+		c.res.WriteHeader(code)
+		return nil
+	}
 	c.w.WriteHeader(code)
 	return c.router.srv.enc(&c.w, c.req, v)
 }
 
+// bodyAllowed reports whether the reply of the status code has a body.
+func bodyAllowed(code int) bool {
+	return code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
 func (c *wrapper) JSON(code int, v interface{}) error {
 	c.res.Header().Set("Content-Type", "application/json")
 	c.res.WriteHeader(code)
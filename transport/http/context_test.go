@@ -10,6 +10,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/go-kratos/kratos/v2/transport"
 )
 
 var testRouter = &Router{srv: NewServer()}
@@ -249,3 +251,30 @@ func TestContextCtx(t *testing.T) {
 		t.Errorf("expected %v, got %v", nil, v)
 	}
 }
+
+func TestContextReplyStatus(t *testing.T) {
+	res := httptest.NewRecorder()
+	tr := &Transport{}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(transport.NewServerContext(req.Context(), tr))
+	w := wrapper{router: testRouter, req: req, res: res}
+	w.w.reset(res)
+	SetReplyStatus(req.Context(), http.StatusNotModified)
+	if err := w.Result(http.StatusOK, map[string]string{"name": "kratos"}); err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != http.StatusNotModified || res.Body.Len() != 0 {
+		t.Errorf("expected %v without body, got %v %q", http.StatusNotModified, res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	w.res = res
+	w.w.reset(res)
+	SetReplyStatus(req.Context(), http.StatusAccepted)
+	if err := w.Returns(map[string]string{"name": "kratos"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != http.StatusAccepted || res.Body.Len() == 0 {
+		t.Errorf("expected %v with body, got %v %q", http.StatusAccepted, res.Code, res.Body.String())
+	}
+}
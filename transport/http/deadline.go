@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// TimeoutHeader is the header carrying the remaining deadline budget of the request
+// as a duration string, e.g. "1.5s". The clients send it with WithDeadlinePropagation,
+// and the servers honour it with DeadlinePropagation.
+const TimeoutHeader = "X-Kratos-Timeout"
+
+// formatTimeout formats the budget in the millisecond precision.
+func formatTimeout(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return d.Truncate(time.Millisecond).String()
+}
+
+// serverTimeout returns the timeout of the request, the budget of the timeout header
+// shortens the timeout of the operation, exhausted is true if the budget is used up.
+func (s *Server) serverTimeout(operation, header string) (timeout time.Duration, exhausted bool) {
+	timeout = s.timeout
+	if t, ok := s.timeouts.Timeout(operation); ok {
+		timeout = t
+	}
+	if !s.propagate || header == "" {
+		return timeout, false
+	}
+	budget, err := time.ParseDuration(header)
+	if err != nil {
+		return timeout, false
+	}
+	if budget <= 0 {
+		return 0, true
+	}
+	if timeout <= 0 || budget < timeout {
+		timeout = budget
+	}
+	return timeout, false
+}
+
+// deadlineExhausted logs the request rejected because its deadline budget is exhausted.
+func (s *Server) deadlineExhausted(ctx context.Context, operation string) {
+	log.Context(ctx).Warnw("msg", "request rejected by the exhausted deadline budget", "kind", transport.KindHTTP.String(), "operation", operation)
+	if s.exhausted != nil {
+		s.exhausted(ctx, transport.KindHTTP, operation)
+	}
+}
+
+// operationTimeout applies the timeout of the operation set by the generated handlers,
+// which is unknown when the server filter applies the timeout of the path template.
+func (s *Server) operationTimeout(tr transport.Transporter, h middleware.Handler) middleware.Handler {
+	if ht, ok := tr.(Transporter); !ok || tr.Operation() == ht.PathTemplate() {
+		return h
+	}
+	timeout, ok := s.timeouts.Timeout(tr.Operation())
+	if !ok || timeout <= 0 {
+		return h
+	}
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return h(ctx, req)
+	}
+}
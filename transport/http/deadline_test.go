@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+func TestDeadlinePropagation(t *testing.T) {
+	var exhausted []string
+	srv := NewServer(
+		Timeout(5*time.Second),
+		OperationTimeouts(transport.NewTimeouts(map[string]time.Duration{"/budget/*": 3 * time.Second})),
+		DeadlinePropagation(),
+		DeadlineExhaustedHandler(func(_ context.Context, kind transport.Kind, operation string) {
+			exhausted = append(exhausted, kind.String()+" "+operation)
+		}),
+	)
+	srv.Route("/").GET("/budget/{name}", func(ctx Context) error {
+		deadline, _ := ctx.Deadline()
+		return ctx.Result(http.StatusOK, map[string]string{"remaining": time.Until(deadline).String()})
+	})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client, err := NewClient(context.Background(), WithEndpoint(ts.Listener.Addr().String()), WithTimeout(10*time.Second), WithDeadlinePropagation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	remaining := func(ctx context.Context) time.Duration {
+		var reply map[string]string
+		if err = client.Invoke(ctx, http.MethodGet, "/budget/a", nil, &reply); err != nil {
+			t.Fatal(err)
+		}
+		d, _ := time.ParseDuration(reply["remaining"])
+		return d
+	}
+	// the operation timeout
+	if d := remaining(context.Background()); d <= time.Second || d > 3*time.Second {
+		t.Errorf("expect the operation timeout, got %v", d)
+	}
+	// the budget of the caller
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if d := remaining(ctx); d <= 0 || d > time.Second {
+		t.Errorf("expect the caller budget, got %v", d)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/budget/a", nil)
+	req.Header.Set(TimeoutHeader, "0s")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("expect %v, got %v", http.StatusGatewayTimeout, resp.StatusCode)
+	}
+	if len(exhausted) != 1 || exhausted[0] != "http /budget/{name}" {
+		t.Errorf("unexpected exhausted requests: %v", exhausted)
+	}
+}
+
+func TestDeadlinePropagationDisabled(t *testing.T) {
+	srv := NewServer(Timeout(5 * time.Second))
+	srv.Route("/").GET("/budget", func(ctx Context) error {
+		deadline, _ := ctx.Deadline()
+		return ctx.Result(http.StatusOK, map[string]string{"remaining": time.Until(deadline).String()})
+	})
+	for _, header := range []string{"0s", "100ms"} {
+		req := httptest.NewRequest(http.MethodGet, "/budget", nil)
+		req.Header.Set(TimeoutHeader, header)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		var reply map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+			t.Fatal(err)
+		}
+		if d, _ := time.ParseDuration(reply["remaining"]); d <= time.Second {
+			t.Errorf("%s: expect the header ignored, got %v", header, reply["remaining"])
+		}
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	srv := NewServer(
+		Timeout(5*time.Second),
+		OperationTimeouts(transport.NewTimeouts(map[string]time.Duration{"/test.Budget/*": 2 * time.Second})),
+	)
+	srv.Route("/").GET("/op", func(ctx Context) error {
+		SetOperation(ctx, "/test.Budget/Get")
+		h := ctx.Middleware(func(ctx context.Context, _ interface{}) (interface{}, error) {
+			deadline, _ := ctx.Deadline()
+			return map[string]string{"remaining": time.Until(deadline).String()}, nil
+		})
+		reply, err := h(ctx, nil)
+		if err != nil {
+			return err
+		}
+		return ctx.Result(http.StatusOK, reply)
+	})
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/op", nil))
+	var reply map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := time.ParseDuration(reply["remaining"]); d <= time.Second || d > 2*time.Second {
+		t.Errorf("expect the timeout of the operation, got %v", reply["remaining"])
+	}
+}
+
+func TestClientDeadlinePropagation(t *testing.T) {
+	var header string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(TimeoutHeader)
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+	for _, propagate := range []bool{false, true} {
+		opts := []ClientOption{WithEndpoint(ts.Listener.Addr().String()), WithTimeout(time.Second)}
+		if propagate {
+			opts = append(opts, WithDeadlinePropagation())
+		}
+		client, err := NewClient(context.Background(), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = client.Invoke(context.Background(), http.MethodGet, "/", nil, &map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+		client.Close()
+		if (header != "") != propagate {
+			t.Errorf("propagate %v: unexpected header %q", propagate, header)
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package http
 
-import "net/http"
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
 
 // FilterFunc is a function which receives a http.Handler and returns another http.Handler.
 type FilterFunc func(http.Handler) http.Handler
@@ -14,3 +20,122 @@ func FilterChain(filters ...FilterFunc) FilterFunc {
 		return next
 	}
 }
+
+// PathTemplateRecorder is implemented by the response writers of filters which
+// need the matched path template after routing, e.g. access logs, since
+// the server filters run before the router matches the request.
+type PathTemplateRecorder interface {
+	RecordPathTemplate(pathTemplate string)
+}
+
+// RecordingWriter is the response writer of filters records the status, the size and
+// the path template of the response, e.g. for access logs and metrics. The path template
+// is forwarded to the writers it wraps, so that all the stacked filters receive it.
+type RecordingWriter struct {
+	http.ResponseWriter
+	status       int
+	size         int64
+	wroteHeader  bool
+	pathTemplate string
+}
+
+// NewRecordingWriter new a RecordingWriter wraps w.
+func NewRecordingWriter(w http.ResponseWriter) *RecordingWriter {
+	return &RecordingWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status returns the status code of the response.
+func (w *RecordingWriter) Status() int {
+	return w.status
+}
+
+// Size returns the written bytes of the response body.
+func (w *RecordingWriter) Size() int64 {
+	return w.size
+}
+
+// PathTemplate returns the matched path template, empty if the request isn't routed by the server.
+func (w *RecordingWriter) PathTemplate() string {
+	return w.pathTemplate
+}
+
+func (w *RecordingWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *RecordingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// RecordPathTemplate implements PathTemplateRecorder.
+func (w *RecordingWriter) RecordPathTemplate(pathTemplate string) {
+	w.pathTemplate = pathTemplate
+	recordPathTemplate(w.ResponseWriter, pathTemplate)
+}
+
+func (w *RecordingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *RecordingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		w.status = http.StatusSwitchingProtocols
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("http: response writer does not support hijacking")
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *RecordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// recordPathTemplate records the path template to the first recorder found by unwrapping w.
+func recordPathTemplate(w http.ResponseWriter, pathTemplate string) {
+	for w != nil {
+		switch v := w.(type) {
+		case PathTemplateRecorder:
+			v.RecordPathTemplate(pathTemplate)
+			return
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return
+		}
+	}
+}
+
+// CountReader counts the bytes read from the request body.
+type CountReader struct {
+	io.ReadCloser
+	n int64
+}
+
+// CountBody replaces the body of req with a CountReader.
+func CountBody(req *http.Request) *CountReader {
+	r := &CountReader{ReadCloser: req.Body}
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = r
+	}
+	return r
+}
+
+// Count returns the bytes read.
+func (r *CountReader) Count() int64 {
+	return r.n
+}
+
+func (r *CountReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.n += int64(n)
+	return n, err
+}
@@ -56,6 +56,33 @@ func Timeout(timeout time.Duration) ServerOption {
 	}
 }
 
+// OperationTimeouts with the per-operation timeouts overriding the server timeout. The timeouts are keyed
+// by the operation as on gRPC, i.e. the full method name set by the generated handlers,
+// e.g. /helloworld.Greeter/SayHello, or the path template of the other routes. The timeouts of
+// the generated handlers are applied after routing, so they can only shorten the server timeout.
+func OperationTimeouts(timeouts *transport.Timeouts) ServerOption {
+	return func(s *Server) {
+		s.timeouts = timeouts
+	}
+}
+
+// DeadlinePropagation with the deadline budget of the callers honoured, the server timeout is shortened
+// to the budget of the TimeoutHeader sent by the clients with WithDeadlinePropagation, and the requests
+// of the exhausted budget are rejected with transport.ErrDeadlineExceeded, as the gRPC server does.
+func DeadlinePropagation() ServerOption {
+	return func(s *Server) {
+		s.propagate = true
+	}
+}
+
+// DeadlineExhaustedHandler with the func called for the requests rejected because their deadline
+// budget is exhausted, e.g. metrics.DeadlineExhaustedCounter, the requests are logged anyway.
+func DeadlineExhaustedHandler(fn transport.DeadlineExhaustedFunc) ServerOption {
+	return func(s *Server) {
+		s.exhausted = fn
+	}
+}
+
 // Logger with server logger.
 // Deprecated: use global logger instead.
 func Logger(_ log.Logger) ServerOption {
@@ -163,6 +190,9 @@ type Server struct {
 	network     string
 	address     string
 	timeout     time.Duration
+	timeouts    *transport.Timeouts
+	propagate   bool
+	exhausted   transport.DeadlineExhaustedFunc
 	filters     []FilterFunc
 	middleware  matcher.Matcher
 	decVars     DecodeRequestFunc
@@ -273,23 +303,30 @@ func (s *Server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 func (s *Server) filter() mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			pathTemplate := req.URL.Path
+			if route := mux.CurrentRoute(req); route != nil {
+				// /path/123 -> /path/{id}
+				pathTemplate, _ = route.GetPathTemplate()
+			}
+			recordPathTemplate(w, pathTemplate)
+
+			timeout, exhausted := s.serverTimeout(pathTemplate, req.Header.Get(TimeoutHeader))
+			if exhausted {
+				s.deadlineExhausted(req.Context(), pathTemplate)
+				s.ene(w, req, transport.ErrDeadlineExceeded)
+				return
+			}
 			var (
 				ctx    context.Context
 				cancel context.CancelFunc
 			)
-			if s.timeout > 0 {
-				ctx, cancel = context.WithTimeout(req.Context(), s.timeout)
+			if timeout > 0 {
+				ctx, cancel = context.WithTimeout(req.Context(), timeout)
 			} else {
 				ctx, cancel = context.WithCancel(req.Context())
 			}
 			defer cancel()
 
-			pathTemplate := req.URL.Path
-			if route := mux.CurrentRoute(req); route != nil {
-				// /path/123 -> /path/{id}
-				pathTemplate, _ = route.GetPathTemplate()
-			}
-
 			tr := &Transport{
 				operation:    pathTemplate,
 				pathTemplate: pathTemplate,
@@ -300,6 +337,7 @@ func (s *Server) filter() mux.MiddlewareFunc {
 			if s.endpoint != nil {
 				tr.endpoint = s.endpoint.String()
 			}
+			tr.peer, _ = transport.PeerFromTLS(req.TLS, s.tlsConf)
 			tr.request = req.WithContext(transport.NewServerContext(ctx, tr))
 			next.ServeHTTP(w, tr.request)
 		})
@@ -7,7 +7,10 @@ import (
 	"github.com/go-kratos/kratos/v2/transport"
 )
 
-var _ Transporter = (*Transport)(nil)
+var (
+	_ Transporter               = (*Transport)(nil)
+	_ transport.PeerTransporter = (*Transport)(nil)
+)
 
 // Transporter is http Transporter
 type Transporter interface {
@@ -24,6 +27,8 @@ type Transport struct {
 	replyHeader  headerCarrier
 	request      *http.Request
 	pathTemplate string
+	peer         *transport.Peer
+	replyStatus  int
 }
 
 // Kind returns the transport kind.
@@ -56,6 +61,11 @@ func (tr *Transport) ReplyHeader() transport.Header {
 	return tr.replyHeader
 }
 
+// Peer returns the identity of the verified client certificate.
+func (tr *Transport) Peer() (*transport.Peer, bool) {
+	return tr.peer, tr.peer != nil
+}
+
 // PathTemplate returns the http path template.
 func (tr *Transport) PathTemplate() string {
 	return tr.pathTemplate
@@ -110,3 +120,13 @@ func (hc headerCarrier) Keys() []string {
 func (hc headerCarrier) Values(key string) []string {
 	return http.Header(hc).Values(key)
 }
+
+// SetReplyStatus sets the status code the reply is written with by Context.Result and Context.Returns,
+// the reply is written without the body if the status code doesn't allow one, e.g. 304 Not Modified.
+func SetReplyStatus(ctx context.Context, code int) {
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if tr, ok := tr.(*Transport); ok {
+			tr.replyStatus = code
+		}
+	}
+}
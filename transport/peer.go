@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+)
+
+// Peer is the identity of the verified client certificate.
+type Peer struct {
+	// Subject is the distinguished name of the certificate subject.
+	Subject string
+	// CommonName is the common name of the certificate subject.
+	CommonName string
+	// DNSNames is the DNS SANs.
+	DNSNames []string
+	// URIs is the URI SANs.
+	URIs []string
+	// EmailAddresses is the email SANs.
+	EmailAddresses []string
+	// IPAddresses is the IP SANs.
+	IPAddresses []net.IP
+	// SPIFFEID is the SPIFFE ID, the URI SAN of the spiffe scheme,
+	// it is empty unless the certificate has exactly one URI SAN like a SPIFFE X.509-SVID.
+	SPIFFEID string
+	// Certificate is the leaf certificate.
+	Certificate *x509.Certificate
+}
+
+// PeerTransporter is the server transport knows the identity of the peer.
+type PeerTransporter interface {
+	Transporter
+	// Peer returns the peer identity, ok is false if the client certificate
+	// is absent or not verified.
+	Peer() (peer *Peer, ok bool)
+}
+
+// NewPeer returns the peer identity of the leaf certificate.
+func NewPeer(cert *x509.Certificate) *Peer {
+	p := &Peer{
+		Subject:        cert.Subject.String(),
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		IPAddresses:    cert.IPAddresses,
+		Certificate:    cert,
+	}
+	for _, uri := range cert.URIs {
+		p.URIs = append(p.URIs, uri.String())
+	}
+	if len(cert.URIs) == 1 && cert.URIs[0].Scheme == "spiffe" {
+		p.SPIFFEID = p.URIs[0]
+	}
+	return p
+}
+
+// PeerFromTLS returns the peer identity of the verified client certificate of the connection.
+// The certificate is the leaf of the verified chains, or the first peer certificate if conf
+// verifies the certificates itself by VerifyPeerCertificate or VerifyConnection, e.g. SPIFFE
+// with the RequireAnyClientCert client auth.
+func PeerFromTLS(state *tls.ConnectionState, conf *tls.Config) (*Peer, bool) {
+	if state == nil {
+		return nil, false
+	}
+	if len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+		return NewPeer(state.VerifiedChains[0][0]), true
+	}
+	if conf != nil && (conf.VerifyPeerCertificate != nil || conf.VerifyConnection != nil) && len(state.PeerCertificates) > 0 {
+		return NewPeer(state.PeerCertificates[0]), true
+	}
+	return nil, false
+}
+
+// PeerFromServerContext returns the peer identity of the server transport in ctx, if any.
+func PeerFromServerContext(ctx context.Context) (*Peer, bool) {
+	tr, ok := FromServerContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	pt, ok := tr.(PeerTransporter)
+	if !ok {
+		return nil, false
+	}
+	return pt.Peer()
+}
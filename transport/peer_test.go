@@ -0,0 +1,51 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+)
+
+type peerTransport struct {
+	mockTransport
+	peer *Peer
+}
+
+func (tr *peerTransport) Peer() (*Peer, bool) { return tr.peer, tr.peer != nil }
+
+func TestPeer(t *testing.T) {
+	web, _ := url.Parse("https://example.org/workload")
+	id, _ := url.Parse("spiffe://example.org/ns/prod/sa/client")
+	cert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "client", Organization: []string{"kratos"}},
+		DNSNames: []string{"client.example.org"},
+		URIs:     []*url.URL{web, id},
+	}
+	if _, ok := PeerFromTLS(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}, &tls.Config{}); ok {
+		t.Fatal("expect the unverified certificate ignored")
+	}
+	p, ok := PeerFromTLS(&tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}, nil)
+	if !ok {
+		t.Fatal("expect peer")
+	}
+	// the certificate of more than one URI SAN isn't a SPIFFE X.509-SVID
+	if p.SPIFFEID != "" || p.CommonName != "client" || p.Subject != "CN=client,O=kratos" || len(p.URIs) != 2 {
+		t.Errorf("unexpected peer %+v", p)
+	}
+	cert.URIs = []*url.URL{id}
+	conf := &tls.Config{VerifyPeerCertificate: func([][]byte, [][]*x509.Certificate) error { return nil }}
+	if p, ok = PeerFromTLS(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}, conf); !ok || p.SPIFFEID != id.String() {
+		t.Errorf("expect the certificate of the custom verification, got %+v", p)
+	}
+
+	ctx := NewServerContext(context.Background(), &peerTransport{peer: p})
+	if got, ok := PeerFromServerContext(ctx); !ok || got != p {
+		t.Errorf("expect %v, got %v", p, got)
+	}
+	if _, ok = PeerFromServerContext(NewServerContext(context.Background(), &mockTransport{})); ok {
+		t.Error("expect no peer")
+	}
+}
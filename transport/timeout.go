@@ -0,0 +1,64 @@
+package transport
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// ErrDeadlineExceeded is the error of the requests rejected because their deadline budget is exhausted.
+var ErrDeadlineExceeded = errors.GatewayTimeout("DEADLINE_EXCEEDED", "request deadline budget exhausted")
+
+// Timeouts is the per-operation timeouts overriding the server timeout.
+// The operations ending with '*' match by prefix, e.g. "/helloworld.Greeter/*".
+// See the transport/timeout package to load them from the config.
+type Timeouts struct {
+	timeouts atomic.Value // map[string]time.Duration
+}
+
+// NewTimeouts returns the per-operation timeouts.
+func NewTimeouts(timeouts map[string]time.Duration) *Timeouts {
+	t := &Timeouts{}
+	t.Update(timeouts)
+	return t
+}
+
+// Update replaces the timeouts.
+func (t *Timeouts) Update(timeouts map[string]time.Duration) {
+	m := make(map[string]time.Duration, len(timeouts))
+	for operation, timeout := range timeouts {
+		m[operation] = timeout
+	}
+	t.timeouts.Store(m)
+}
+
+// Timeout returns the timeout of the operation, the exact match is preferred
+// over the longest prefix match.
+func (t *Timeouts) Timeout(operation string) (time.Duration, bool) {
+	if t == nil {
+		return 0, false
+	}
+	m, _ := t.timeouts.Load().(map[string]time.Duration)
+	if timeout, ok := m[operation]; ok {
+		return timeout, true
+	}
+	var (
+		timeout time.Duration
+		longest = -1
+	)
+	for pattern, d := range m {
+		prefix := strings.TrimSuffix(pattern, "*")
+		if len(prefix) == len(pattern) || len(prefix) <= longest || !strings.HasPrefix(operation, prefix) {
+			continue
+		}
+		timeout, longest = d, len(prefix)
+	}
+	return timeout, longest >= 0
+}
+
+// DeadlineExhaustedFunc is called for the requests rejected because their deadline budget is exhausted,
+// e.g. to count them, see the DeadlineExhaustedHandler options of the servers.
+type DeadlineExhaustedFunc func(ctx context.Context, kind Kind, operation string)
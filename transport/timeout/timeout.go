@@ -0,0 +1,45 @@
+// Package timeout loads the per-operation timeouts of the servers from the config.
+package timeout
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Watch loads the timeouts of the key, a map of the operation to the duration string, e.g. "500ms",
+// and updates them on changes.
+func Watch(c config.Config, key string, timeouts *transport.Timeouts) error {
+	values, err := scan(c.Value(key))
+	if err != nil {
+		return err
+	}
+	timeouts.Update(values)
+	return c.Watch(key, func(_ string, v config.Value) {
+		values, err := scan(v)
+		if err != nil {
+			log.Errorf("timeout: failed to scan timeouts: %v", err)
+			return
+		}
+		timeouts.Update(values)
+	})
+}
+
+func scan(v config.Value) (map[string]time.Duration, error) {
+	var values map[string]string
+	if err := v.Scan(&values); err != nil {
+		return nil, err
+	}
+	timeouts := make(map[string]time.Duration, len(values))
+	for operation, value := range values {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout of %s: %w", operation, err)
+		}
+		timeouts[operation] = d
+	}
+	return timeouts, nil
+}
@@ -0,0 +1,42 @@
+package timeout
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/file"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.json")
+	if err := os.WriteFile(path, []byte(`{"server": {"timeouts": {"/helloworld.Greeter/SayHello": "500ms"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := config.New(config.WithSource(file.NewSource(path)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	timeouts := transport.NewTimeouts(nil)
+	if err := Watch(c, "server.timeouts", timeouts); err != nil {
+		t.Fatal(err)
+	}
+	if timeout, _ := timeouts.Timeout("/helloworld.Greeter/SayHello"); timeout != 500*time.Millisecond {
+		t.Errorf("expect %v, got %v", 500*time.Millisecond, timeout)
+	}
+	if err := os.WriteFile(path, []byte(`{"server": {"timeouts": {"/helloworld.Greeter/SayHello": "2s"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if timeout, _ := timeouts.Timeout("/helloworld.Greeter/SayHello"); timeout == 2*time.Second {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Error("expect the timeouts updated")
+}
@@ -0,0 +1,33 @@
+package transport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeouts(t *testing.T) {
+	timeouts := NewTimeouts(map[string]time.Duration{
+		"/helloworld.Greeter/SayHello": time.Second,
+		"/helloworld.Greeter/*":        2 * time.Second,
+		"/helloworld.*":                3 * time.Second,
+	})
+	tests := []struct {
+		operation string
+		timeout   time.Duration
+		ok        bool
+	}{
+		{"/helloworld.Greeter/SayHello", time.Second, true},
+		{"/helloworld.Greeter/SayBye", 2 * time.Second, true},
+		{"/helloworld.Other/Get", 3 * time.Second, true},
+		{"/other.Service/Get", 0, false},
+	}
+	for _, test := range tests {
+		if timeout, ok := timeouts.Timeout(test.operation); timeout != test.timeout || ok != test.ok {
+			t.Errorf("%s: expect %v %v, got %v %v", test.operation, test.timeout, test.ok, timeout, ok)
+		}
+	}
+	var nilTimeouts *Timeouts
+	if _, ok := nilTimeouts.Timeout("/helloworld.Greeter/SayHello"); ok {
+		t.Error("expect no timeout of nil timeouts")
+	}
+}